@@ -0,0 +1,63 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package example is used to verify that brookgen writes the same bits as brookstruct
+package example
+
+//go:generate go run github.com/piot/brook-go/cmd/brookgen -type Player
+
+type Weapon uint8
+
+type Vector struct {
+	X float32 `brook:"quant=-10..10,bits=12"`
+	Y float32 `brook:"quant=-10..10,bits=12"`
+}
+
+type Item struct {
+	Kind  uint16 `brook:"bits=10"`
+	Count int8
+}
+
+type Player struct {
+	ID       uint16
+	Health   uint8 `brook:"bits=7"`
+	Offset   int32 `brook:"range=-100..100"`
+	Drift    int8  `brook:"bits=5"`
+	Alive    bool
+	Weapon   Weapon `brook:"bits=3"`
+	Name     string `brook:"max=16"`
+	Position Vector
+	Items    []Item  `brook:"max=8"`
+	Flags    []uint8 `brook:"bits=4,max=8"`
+	Slots    [2]int16
+	Target   *Vector
+	Score    int64
+	Speed    float64
+	Raw      float32
+	Big      uint64
+	Cache    int `brook:"skip"`
+	internal int
+}
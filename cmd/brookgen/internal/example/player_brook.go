@@ -0,0 +1,276 @@
+// Code generated by brookgen. DO NOT EDIT.
+
+package example
+
+import (
+	"math"
+
+	"github.com/piot/brook-go/src/brookstruct"
+	"github.com/piot/brook-go/src/inbitstream"
+	"github.com/piot/brook-go/src/outbitstream"
+)
+
+// MarshalBrook : Writes Player to the bit stream
+func (v *Player) MarshalBrook(out outbitstream.OutBitStream) error {
+	if err := out.WriteUint16(uint16(v.ID)); err != nil {
+		return err
+	}
+	if err := brookstruct.WriteUnsignedBits(out, uint64(v.Health), 7); err != nil {
+		return err
+	}
+	if err := brookstruct.WriteRange(out, int64(v.Offset), -100, 100); err != nil {
+		return err
+	}
+	if err := brookstruct.WriteSignedBits(out, int64(v.Drift), 5); err != nil {
+		return err
+	}
+	if err := outbitstream.WriteBool(out, bool(v.Alive)); err != nil {
+		return err
+	}
+	if err := brookstruct.WriteUnsignedBits(out, uint64(v.Weapon), 3); err != nil {
+		return err
+	}
+	if err := brookstruct.WriteString(out, string(v.Name), 5, 16); err != nil {
+		return err
+	}
+	if err := v.Position.MarshalBrook(out); err != nil {
+		return err
+	}
+	if err := brookstruct.WriteLength(out, len(v.Items), 4, 8); err != nil {
+		return err
+	}
+	for i0 := range v.Items {
+		if err := v.Items[i0].MarshalBrook(out); err != nil {
+			return err
+		}
+	}
+	if err := brookstruct.WriteLength(out, len(v.Flags), 4, 8); err != nil {
+		return err
+	}
+	for i0 := range v.Flags {
+		if err := brookstruct.WriteUnsignedBits(out, uint64(v.Flags[i0]), 4); err != nil {
+			return err
+		}
+	}
+	for i0 := range v.Slots {
+		if err := brookstruct.WriteSignedBits(out, int64(v.Slots[i0]), 16); err != nil {
+			return err
+		}
+	}
+	if err := outbitstream.WriteBool(out, v.Target != nil); err != nil {
+		return err
+	}
+	if v.Target != nil {
+		if err := (*v.Target).MarshalBrook(out); err != nil {
+			return err
+		}
+	}
+	if err := out.WriteUint64(uint64(v.Score)); err != nil {
+		return err
+	}
+	if err := out.WriteUint64(math.Float64bits(float64(v.Speed))); err != nil {
+		return err
+	}
+	if err := out.WriteUint32(math.Float32bits(float32(v.Raw))); err != nil {
+		return err
+	}
+	if err := out.WriteUint64(uint64(v.Big)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// UnmarshalBrook : Reads Player from the bit stream
+func (v *Player) UnmarshalBrook(in inbitstream.InBitStream) error {
+	{
+		x, err := in.ReadUint16()
+		if err != nil {
+			return err
+		}
+		v.ID = uint16(x)
+	}
+	{
+		x, err := in.ReadBits(7)
+		if err != nil {
+			return err
+		}
+		v.Health = uint8(x)
+	}
+	{
+		x, err := brookstruct.ReadRange(in, -100, 100)
+		if err != nil {
+			return err
+		}
+		v.Offset = int32(x)
+	}
+	{
+		x, err := in.ReadSignedBits(5)
+		if err != nil {
+			return err
+		}
+		v.Drift = int8(x)
+	}
+	{
+		x, err := inbitstream.ReadBool(in)
+		if err != nil {
+			return err
+		}
+		v.Alive = bool(x)
+	}
+	{
+		x, err := in.ReadBits(3)
+		if err != nil {
+			return err
+		}
+		v.Weapon = Weapon(x)
+	}
+	{
+		x, err := in.ReadString(5, 16)
+		if err != nil {
+			return err
+		}
+		v.Name = string(x)
+	}
+	if err := v.Position.UnmarshalBrook(in); err != nil {
+		return err
+	}
+	{
+		count, err := brookstruct.ReadLength(in, 4, 8)
+		if err != nil {
+			return err
+		}
+		v.Items = make([]Item, count)
+	}
+	for i0 := range v.Items {
+		if err := v.Items[i0].UnmarshalBrook(in); err != nil {
+			return err
+		}
+	}
+	{
+		count, err := brookstruct.ReadLength(in, 4, 8)
+		if err != nil {
+			return err
+		}
+		v.Flags = make([]uint8, count)
+	}
+	for i0 := range v.Flags {
+		{
+			x, err := in.ReadBits(4)
+			if err != nil {
+				return err
+			}
+			v.Flags[i0] = uint8(x)
+		}
+	}
+	for i0 := range v.Slots {
+		{
+			x, err := in.ReadInt16()
+			if err != nil {
+				return err
+			}
+			v.Slots[i0] = int16(x)
+		}
+	}
+	{
+		present, err := inbitstream.ReadBool(in)
+		if err != nil {
+			return err
+		}
+		v.Target = nil
+		if present {
+			v.Target = new(Vector)
+			if err := (*v.Target).UnmarshalBrook(in); err != nil {
+				return err
+			}
+		}
+	}
+	{
+		x, err := in.ReadUint64()
+		if err != nil {
+			return err
+		}
+		v.Score = int64(x)
+	}
+	{
+		x, err := in.ReadUint64()
+		if err != nil {
+			return err
+		}
+		v.Speed = float64(math.Float64frombits(x))
+	}
+	{
+		x, err := in.ReadUint32()
+		if err != nil {
+			return err
+		}
+		v.Raw = float32(math.Float32frombits(x))
+	}
+	{
+		x, err := in.ReadUint64()
+		if err != nil {
+			return err
+		}
+		v.Big = uint64(x)
+	}
+	return nil
+}
+
+// MarshalBrook : Writes Vector to the bit stream
+func (v *Vector) MarshalBrook(out outbitstream.OutBitStream) error {
+	if err := brookstruct.WriteQuantized(out, float64(v.X), -10, 10, 12); err != nil {
+		return err
+	}
+	if err := brookstruct.WriteQuantized(out, float64(v.Y), -10, 10, 12); err != nil {
+		return err
+	}
+	return nil
+}
+
+// UnmarshalBrook : Reads Vector from the bit stream
+func (v *Vector) UnmarshalBrook(in inbitstream.InBitStream) error {
+	{
+		x, err := brookstruct.ReadQuantized(in, -10, 10, 12)
+		if err != nil {
+			return err
+		}
+		v.X = float32(x)
+	}
+	{
+		x, err := brookstruct.ReadQuantized(in, -10, 10, 12)
+		if err != nil {
+			return err
+		}
+		v.Y = float32(x)
+	}
+	return nil
+}
+
+// MarshalBrook : Writes Item to the bit stream
+func (v *Item) MarshalBrook(out outbitstream.OutBitStream) error {
+	if err := brookstruct.WriteUnsignedBits(out, uint64(v.Kind), 10); err != nil {
+		return err
+	}
+	if err := brookstruct.WriteSignedBits(out, int64(v.Count), 8); err != nil {
+		return err
+	}
+	return nil
+}
+
+// UnmarshalBrook : Reads Item from the bit stream
+func (v *Item) UnmarshalBrook(in inbitstream.InBitStream) error {
+	{
+		x, err := in.ReadBits(10)
+		if err != nil {
+			return err
+		}
+		v.Kind = uint16(x)
+	}
+	{
+		x, err := in.ReadSignedBits(8)
+		if err != nil {
+			return err
+		}
+		v.Count = int8(x)
+	}
+	return nil
+}
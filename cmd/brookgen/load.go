@@ -0,0 +1,226 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/piot/brook-go/src/brookstruct"
+	"github.com/piot/brook-go/src/codegen"
+)
+
+type loader struct {
+	packageName string
+	typeSpecs   map[string]*ast.TypeSpec
+	structs     map[string]*codegen.Struct
+	order       []string
+	pending     []string
+}
+
+func newLoader(dir string) (*loader, error) {
+	fileSet := token.NewFileSet()
+	paths, globErr := filepath.Glob(filepath.Join(dir, "*.go"))
+	if globErr != nil {
+		return nil, globErr
+	}
+	l := &loader{typeSpecs: map[string]*ast.TypeSpec{}, structs: map[string]*codegen.Struct{}}
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, parseErr := parser.ParseFile(fileSet, path, nil, 0)
+		if parseErr != nil {
+			return nil, parseErr
+		}
+		if l.packageName == "" {
+			l.packageName = file.Name.Name
+		}
+		for _, decl := range file.Decls {
+			genDecl, isGenDecl := decl.(*ast.GenDecl)
+			if !isGenDecl || genDecl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				l.typeSpecs[typeSpec.Name.Name] = typeSpec
+			}
+		}
+	}
+	if l.packageName == "" {
+		return nil, fmt.Errorf("no Go files found in %v", dir)
+	}
+	return l, nil
+}
+
+// load resolves the named structs and every struct they refer to
+func (l *loader) load(typeNames []string) ([]codegen.Struct, error) {
+	l.pending = append(l.pending, typeNames...)
+	for len(l.pending) > 0 {
+		name := l.pending[0]
+		l.pending = l.pending[1:]
+		if _, done := l.structs[name]; done {
+			continue
+		}
+		s, structErr := l.loadStruct(name)
+		if structErr != nil {
+			return nil, structErr
+		}
+		l.structs[name] = s
+		l.order = append(l.order, name)
+	}
+
+	structs := make([]codegen.Struct, len(l.order))
+	for i, name := range l.order {
+		structs[i] = *l.structs[name]
+	}
+	return structs, nil
+}
+
+func (l *loader) loadStruct(name string) (*codegen.Struct, error) {
+	typeSpec, found := l.typeSpecs[name]
+	if !found {
+		return nil, fmt.Errorf("type %v not found", name)
+	}
+	structType, isStruct := typeSpec.Type.(*ast.StructType)
+	if !isStruct {
+		return nil, fmt.Errorf("type %v is not a struct", name)
+	}
+
+	s := &codegen.Struct{Name: name}
+	for _, field := range structType.Fields.List {
+		tag := ""
+		if field.Tag != nil {
+			unquoted, unquoteErr := strconv.Unquote(field.Tag.Value)
+			if unquoteErr != nil {
+				return nil, unquoteErr
+			}
+			tag = reflect.StructTag(unquoted).Get("brook")
+		}
+		options, tagErr := brookstruct.ParseTag(tag)
+		if tagErr != nil {
+			return nil, fmt.Errorf("%v: %w", name, tagErr)
+		}
+		if options.Skip {
+			continue
+		}
+
+		names := field.Names
+		if len(names) == 0 {
+			embedded, embeddedErr := embeddedName(field.Type)
+			if embeddedErr != nil {
+				return nil, fmt.Errorf("%v: %w", name, embeddedErr)
+			}
+			names = []*ast.Ident{embedded}
+		}
+		for _, fieldName := range names {
+			if fieldName == nil || !fieldName.IsExported() {
+				continue
+			}
+			fieldType, typeErr := l.resolve(field.Type, options)
+			if typeErr != nil {
+				return nil, fmt.Errorf("%v.%v: %w", name, fieldName.Name, typeErr)
+			}
+			s.Fields = append(s.Fields, codegen.Field{Name: fieldName.Name, Type: fieldType})
+		}
+	}
+	return s, nil
+}
+
+// embeddedName returns the field name of an embedded type. Embedded types from other packages can not be
+// resolved, and skipping them would write other bits than brookstruct, so they are an error
+func embeddedName(expr ast.Expr) (*ast.Ident, error) {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t, nil
+	case *ast.StarExpr:
+		return embeddedName(t.X)
+	case *ast.SelectorExpr:
+		return nil, fmt.Errorf("embedded type %v.%v from another package is not supported", t.X, t.Sel.Name)
+	}
+	return nil, fmt.Errorf("embedded type %T is not supported", expr)
+}
+
+func (l *loader) resolve(expr ast.Expr, options brookstruct.Options) (*codegen.Type, error) {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return l.resolveName(t.Name, t.Name, options)
+	case *ast.StarExpr:
+		elem, elemErr := l.resolve(t.X, options)
+		if elemErr != nil {
+			return nil, elemErr
+		}
+		return codegen.NewPointer(elem), nil
+	case *ast.ArrayType:
+		elemOptions := options
+		if t.Len == nil {
+			elemOptions.Max = 0
+		}
+		elem, elemErr := l.resolve(t.Elt, elemOptions)
+		if elemErr != nil {
+			return nil, elemErr
+		}
+		if t.Len == nil {
+			return codegen.NewSlice(elem, options), nil
+		}
+		lengthLiteral, isLiteral := t.Len.(*ast.BasicLit)
+		if !isLiteral || lengthLiteral.Kind != token.INT {
+			return nil, fmt.Errorf("array length must be an integer literal")
+		}
+		length, lengthErr := strconv.Atoi(lengthLiteral.Value)
+		if lengthErr != nil {
+			return nil, lengthErr
+		}
+		return codegen.NewArray(elem, length), nil
+	}
+	return nil, fmt.Errorf("unsupported type %T", expr)
+}
+
+// resolveName follows named types in the package down to a scalar or a struct
+func (l *loader) resolveName(goName string, name string, options brookstruct.Options) (*codegen.Type, error) {
+	if kind, isScalar := codegen.ScalarKindFromName(name); isScalar {
+		return codegen.NewScalar(goName, kind, options)
+	}
+	typeSpec, found := l.typeSpecs[name]
+	if !found {
+		return nil, fmt.Errorf("type %v must be declared in package %v", name, l.packageName)
+	}
+	switch underlying := typeSpec.Type.(type) {
+	case *ast.StructType:
+		l.pending = append(l.pending, name)
+		return codegen.NewStructRef(goName), nil
+	case *ast.Ident:
+		return l.resolveName(goName, underlying.Name, options)
+	}
+	return nil, fmt.Errorf("type %v is not supported", name)
+}
@@ -0,0 +1,91 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Command brookgen generates MarshalBrook and UnmarshalBrook methods for structs.
+// The methods write exactly the same bits as brookstruct.Marshal, using the same `brook` tags.
+//
+// Usage:
+//
+//	//go:generate go run github.com/piot/brook-go/cmd/brookgen -type Player,Vector
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/piot/brook-go/src/codegen"
+)
+
+func generate(dir string, typeNames []string) ([]byte, error) {
+	l, loadErr := newLoader(dir)
+	if loadErr != nil {
+		return nil, loadErr
+	}
+	structs, structsErr := l.load(typeNames)
+	if structsErr != nil {
+		return nil, structsErr
+	}
+	return codegen.GenerateGo(codegen.GoFile{Package: l.packageName, Generator: "brookgen", Structs: structs})
+}
+
+func run() error {
+	typeList := flag.String("type", "", "comma separated list of struct type names, referenced structs are included automatically")
+	output := flag.String("output", "", "output file name, default <first type>_brook.go")
+	flag.Parse()
+
+	if *typeList == "" {
+		flag.Usage()
+		return fmt.Errorf("-type is required")
+	}
+	typeNames := strings.Split(*typeList, ",")
+
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+
+	source, generateErr := generate(dir, typeNames)
+	if generateErr != nil {
+		return generateErr
+	}
+
+	outputPath := *output
+	if outputPath == "" {
+		outputPath = filepath.Join(dir, strings.ToLower(typeNames[0])+"_brook.go")
+	}
+	return os.WriteFile(outputPath, source, 0o644)
+}
+
+func main() {
+	err := run()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "brookgen: %v\n", err)
+		os.Exit(1)
+	}
+}
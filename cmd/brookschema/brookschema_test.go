@@ -0,0 +1,53 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestGeneratedExampleIsUpToDate(t *testing.T) {
+	source, err := generate("internal/example/game.brook", "go", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	committed, readErr := os.ReadFile("internal/example/game_brook.go")
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	if string(source) != string(committed) {
+		t.Errorf("internal/example/game_brook.go is out of date, run go generate")
+	}
+}
+
+func TestUnknownLanguage(t *testing.T) {
+	_, err := generate("internal/example/game.brook", "cobol", "")
+	if err == nil {
+		t.Errorf("Expected error")
+	}
+}
@@ -0,0 +1,89 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package example
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/piot/brook-go/src/brookstruct"
+	"github.com/piot/brook-go/src/inbitstream"
+	"github.com/piot/brook-go/src/outbitstream"
+)
+
+func testPlayer() Player {
+	return Player{Id: 0xcafe, Health: 99, Offset: -42, Aim: 0.5, Name: "brook", Alive: true, Weapon: WeaponBow,
+		Position: Vector{X: 1.5, Y: -9.25}, Items: []Item{{Id: 1000, Count: 3}, {Id: 2, Count: 99}},
+		Slots: [2]int16{-300, 300}, Target: &Vector{X: 10, Y: -10}, Score: -1, Ratio: 0.25, Total: 1 << 40, Delta: -(1 << 40)}
+}
+
+func TestSameBitsAsReflection(t *testing.T) {
+	player := testPlayer()
+
+	reflected := outbitstream.New(256)
+	reflectErr := brookstruct.Marshal(reflected, &player)
+	if reflectErr != nil {
+		t.Fatal(reflectErr)
+	}
+	reflected.Close()
+
+	generated := outbitstream.New(256)
+	generateErr := player.MarshalBrook(generated)
+	if generateErr != nil {
+		t.Fatal(generateErr)
+	}
+	generated.Close()
+
+	if reflected.Tell() != generated.Tell() || !bytes.Equal(reflected.Octets(), generated.Octets()) {
+		t.Errorf("Expected %X (%v bits) but got %X (%v bits)", reflected.Octets(), reflected.Tell(), generated.Octets(), generated.Tell())
+	}
+
+	var fromGenerated Player
+	readErr := fromGenerated.UnmarshalBrook(inbitstream.New(reflected.Octets(), reflected.Tell()))
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	var fromReflection Player
+	brookstruct.Unmarshal(inbitstream.New(reflected.Octets(), reflected.Tell()), &fromReflection)
+	if !reflect.DeepEqual(fromGenerated, fromReflection) {
+		t.Errorf("Expected %+v but got %+v", fromReflection, fromGenerated)
+	}
+	if fromGenerated.Weapon != WeaponBow || fromGenerated.Name != "brook" || fromGenerated.Target == nil {
+		t.Errorf("Unexpected round trip %+v", fromGenerated)
+	}
+}
+
+func TestStringLongerThanMax(t *testing.T) {
+	player := testPlayer()
+	player.Name = "twenty octets long.."
+	generateErr := player.MarshalBrook(outbitstream.New(256))
+	if !errors.Is(generateErr, outbitstream.ErrOverflow) {
+		t.Errorf("Expected overflow like the C# WriteString helper but got %v", generateErr)
+	}
+}
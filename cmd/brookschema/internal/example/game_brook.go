@@ -0,0 +1,292 @@
+// Code generated by brookschema. DO NOT EDIT.
+
+package example
+
+import (
+	"math"
+
+	"github.com/piot/brook-go/src/brookstruct"
+	"github.com/piot/brook-go/src/inbitstream"
+	"github.com/piot/brook-go/src/outbitstream"
+)
+
+// Weapon : Enum written with 3 bits
+type Weapon uint8
+
+const (
+	WeaponSword Weapon = 0
+	WeaponBow   Weapon = 4
+	WeaponStaff Weapon = 5
+)
+
+// Vector : Message declared in the schema
+type Vector struct {
+	X float32 `brook:"quant=-512..512,bits=16"`
+	Y float32 `brook:"quant=-512..512,bits=16"`
+}
+
+// Item : Message declared in the schema
+type Item struct {
+	Id    uint16
+	Count uint8 `brook:"range=1..99"`
+}
+
+// Player : Message declared in the schema
+type Player struct {
+	Id       uint16
+	Health   uint8   `brook:"bits=7"`
+	Offset   int32   `brook:"range=-100..100"`
+	Aim      float32 `brook:"quant=0..1,bits=10"`
+	Name     string  `brook:"max=16"`
+	Alive    bool
+	Weapon   Weapon `brook:"bits=3"`
+	Position Vector
+	Items    []Item `brook:"max=8"`
+	Slots    [2]int16
+	Target   *Vector
+	Score    int64
+	Ratio    float64
+	Total    uint
+	Delta    int
+}
+
+// MarshalBrook : Writes Vector to the bit stream
+func (v *Vector) MarshalBrook(out outbitstream.OutBitStream) error {
+	if err := brookstruct.WriteQuantized(out, float64(v.X), -512, 512, 16); err != nil {
+		return err
+	}
+	if err := brookstruct.WriteQuantized(out, float64(v.Y), -512, 512, 16); err != nil {
+		return err
+	}
+	return nil
+}
+
+// UnmarshalBrook : Reads Vector from the bit stream
+func (v *Vector) UnmarshalBrook(in inbitstream.InBitStream) error {
+	{
+		x, err := brookstruct.ReadQuantized(in, -512, 512, 16)
+		if err != nil {
+			return err
+		}
+		v.X = float32(x)
+	}
+	{
+		x, err := brookstruct.ReadQuantized(in, -512, 512, 16)
+		if err != nil {
+			return err
+		}
+		v.Y = float32(x)
+	}
+	return nil
+}
+
+// MarshalBrook : Writes Item to the bit stream
+func (v *Item) MarshalBrook(out outbitstream.OutBitStream) error {
+	if err := out.WriteUint16(uint16(v.Id)); err != nil {
+		return err
+	}
+	if err := brookstruct.WriteRange(out, int64(v.Count), 1, 99); err != nil {
+		return err
+	}
+	return nil
+}
+
+// UnmarshalBrook : Reads Item from the bit stream
+func (v *Item) UnmarshalBrook(in inbitstream.InBitStream) error {
+	{
+		x, err := in.ReadUint16()
+		if err != nil {
+			return err
+		}
+		v.Id = uint16(x)
+	}
+	{
+		x, err := brookstruct.ReadRange(in, 1, 99)
+		if err != nil {
+			return err
+		}
+		v.Count = uint8(x)
+	}
+	return nil
+}
+
+// MarshalBrook : Writes Player to the bit stream
+func (v *Player) MarshalBrook(out outbitstream.OutBitStream) error {
+	if err := out.WriteUint16(uint16(v.Id)); err != nil {
+		return err
+	}
+	if err := brookstruct.WriteUnsignedBits(out, uint64(v.Health), 7); err != nil {
+		return err
+	}
+	if err := brookstruct.WriteRange(out, int64(v.Offset), -100, 100); err != nil {
+		return err
+	}
+	if err := brookstruct.WriteQuantized(out, float64(v.Aim), 0, 1, 10); err != nil {
+		return err
+	}
+	if err := brookstruct.WriteString(out, string(v.Name), 5, 16); err != nil {
+		return err
+	}
+	if err := outbitstream.WriteBool(out, bool(v.Alive)); err != nil {
+		return err
+	}
+	if err := brookstruct.WriteUnsignedBits(out, uint64(v.Weapon), 3); err != nil {
+		return err
+	}
+	if err := v.Position.MarshalBrook(out); err != nil {
+		return err
+	}
+	if err := brookstruct.WriteLength(out, len(v.Items), 4, 8); err != nil {
+		return err
+	}
+	for i0 := range v.Items {
+		if err := v.Items[i0].MarshalBrook(out); err != nil {
+			return err
+		}
+	}
+	for i0 := range v.Slots {
+		if err := brookstruct.WriteSignedBits(out, int64(v.Slots[i0]), 16); err != nil {
+			return err
+		}
+	}
+	if err := outbitstream.WriteBool(out, v.Target != nil); err != nil {
+		return err
+	}
+	if v.Target != nil {
+		if err := (*v.Target).MarshalBrook(out); err != nil {
+			return err
+		}
+	}
+	if err := out.WriteUint64(uint64(v.Score)); err != nil {
+		return err
+	}
+	if err := out.WriteUint64(math.Float64bits(float64(v.Ratio))); err != nil {
+		return err
+	}
+	if err := out.WriteUint64(uint64(v.Total)); err != nil {
+		return err
+	}
+	if err := out.WriteUint64(uint64(v.Delta)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// UnmarshalBrook : Reads Player from the bit stream
+func (v *Player) UnmarshalBrook(in inbitstream.InBitStream) error {
+	{
+		x, err := in.ReadUint16()
+		if err != nil {
+			return err
+		}
+		v.Id = uint16(x)
+	}
+	{
+		x, err := in.ReadBits(7)
+		if err != nil {
+			return err
+		}
+		v.Health = uint8(x)
+	}
+	{
+		x, err := brookstruct.ReadRange(in, -100, 100)
+		if err != nil {
+			return err
+		}
+		v.Offset = int32(x)
+	}
+	{
+		x, err := brookstruct.ReadQuantized(in, 0, 1, 10)
+		if err != nil {
+			return err
+		}
+		v.Aim = float32(x)
+	}
+	{
+		x, err := in.ReadString(5, 16)
+		if err != nil {
+			return err
+		}
+		v.Name = string(x)
+	}
+	{
+		x, err := inbitstream.ReadBool(in)
+		if err != nil {
+			return err
+		}
+		v.Alive = bool(x)
+	}
+	{
+		x, err := in.ReadBits(3)
+		if err != nil {
+			return err
+		}
+		v.Weapon = Weapon(x)
+	}
+	if err := v.Position.UnmarshalBrook(in); err != nil {
+		return err
+	}
+	{
+		count, err := brookstruct.ReadLength(in, 4, 8)
+		if err != nil {
+			return err
+		}
+		v.Items = make([]Item, count)
+	}
+	for i0 := range v.Items {
+		if err := v.Items[i0].UnmarshalBrook(in); err != nil {
+			return err
+		}
+	}
+	for i0 := range v.Slots {
+		{
+			x, err := in.ReadInt16()
+			if err != nil {
+				return err
+			}
+			v.Slots[i0] = int16(x)
+		}
+	}
+	{
+		present, err := inbitstream.ReadBool(in)
+		if err != nil {
+			return err
+		}
+		v.Target = nil
+		if present {
+			v.Target = new(Vector)
+			if err := (*v.Target).UnmarshalBrook(in); err != nil {
+				return err
+			}
+		}
+	}
+	{
+		x, err := in.ReadUint64()
+		if err != nil {
+			return err
+		}
+		v.Score = int64(x)
+	}
+	{
+		x, err := in.ReadUint64()
+		if err != nil {
+			return err
+		}
+		v.Ratio = float64(math.Float64frombits(x))
+	}
+	{
+		x, err := in.ReadUint64()
+		if err != nil {
+			return err
+		}
+		v.Total = uint(x)
+	}
+	{
+		x, err := in.ReadUint64()
+		if err != nil {
+			return err
+		}
+		v.Delta = int(x)
+	}
+	return nil
+}
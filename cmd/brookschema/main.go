@@ -0,0 +1,95 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Command brookschema generates serialization code from a brook schema file.
+//
+// Usage:
+//
+//	//go:generate go run github.com/piot/brook-go/cmd/brookschema -output game_brook.go game.brook
+//	//go:generate go run github.com/piot/brook-go/cmd/brookschema -lang csharp -namespace Studio.Game -output Game.cs game.brook
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/piot/brook-go/src/schema"
+)
+
+func generate(schemaPath string, lang string, namespace string) ([]byte, error) {
+	source, readErr := os.ReadFile(schemaPath)
+	if readErr != nil {
+		return nil, readErr
+	}
+	s, parseErr := schema.Parse(string(source))
+	if parseErr != nil {
+		return nil, fmt.Errorf("%v: %w", schemaPath, parseErr)
+	}
+	switch lang {
+	case "go":
+		return schema.GenerateGo(s, "brookschema")
+	case "csharp":
+		return schema.GenerateCSharp(s, schema.CSharpFile{Namespace: namespace, Generator: "brookschema"})
+	}
+	return nil, fmt.Errorf("unknown language %v", lang)
+}
+
+func run() error {
+	lang := flag.String("lang", "go", "target language: go or csharp")
+	namespace := flag.String("namespace", "", "C# namespace, default is the schema package name")
+	output := flag.String("output", "", "output file name, default <schema name>_brook.go or <schema name>.cs")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		return fmt.Errorf("expected one schema file")
+	}
+	schemaPath := flag.Arg(0)
+
+	source, generateErr := generate(schemaPath, *lang, *namespace)
+	if generateErr != nil {
+		return generateErr
+	}
+
+	outputPath := *output
+	if outputPath == "" {
+		outputPath = strings.TrimSuffix(schemaPath, ".brook") + "_brook.go"
+		if *lang == "csharp" {
+			outputPath = strings.TrimSuffix(schemaPath, ".brook") + ".cs"
+		}
+	}
+	return os.WriteFile(outputPath, source, 0o644)
+}
+
+func main() {
+	err := run()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "brookschema: %v\n", err)
+		os.Exit(1)
+	}
+}
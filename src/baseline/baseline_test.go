@@ -0,0 +1,207 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package baseline
+
+import (
+	"testing"
+
+	"github.com/piot/brook-go/src/delta"
+	"github.com/piot/brook-go/src/inbitstream"
+	"github.com/piot/brook-go/src/outbitstream"
+)
+
+func TestSequenceWrap(t *testing.T) {
+	if !Sequence(2).IsNewerThan(0xfffe) {
+		t.Errorf("Expected 2 to be newer than 0xfffe")
+	}
+	if Sequence(0xfffe).Distance(2) != -4 {
+		t.Errorf("Expected distance -4 but got %v", Sequence(0xfffe).Distance(2))
+	}
+	if Sequence(0xffff).Next() != 0 {
+		t.Errorf("Expected wrap to zero")
+	}
+}
+
+func TestStoreExpiry(t *testing.T) {
+	store, err := New(8, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for sequence := Sequence(0xfffd); sequence != 5; sequence++ {
+		addErr := store.Add(sequence, []byte{byte(sequence)}, 8)
+		if addErr != nil {
+			t.Fatal(addErr)
+		}
+	}
+	// Latest is 4, so 0..4 are within max age
+	if _, found := store.Get(0); !found {
+		t.Errorf("Expected sequence 0 to be stored")
+	}
+	if _, found := store.Get(0xffff); found {
+		t.Errorf("Expected sequence 0xffff to be expired")
+	}
+	if _, found := store.Get(5); found {
+		t.Errorf("Expected sequence 5 to be missing")
+	}
+	snapshot, _ := store.Get(3)
+	v, readErr := snapshot.InStream().ReadUint8()
+	if readErr != nil || v != 3 {
+		t.Errorf("Expected to read 3 but got %v %v", v, readErr)
+	}
+}
+
+func TestStoreOverwrittenSlot(t *testing.T) {
+	store, _ := New(2, 100)
+	store.Add(1, []byte{1}, 8)
+	store.Add(2, []byte{2}, 8)
+	store.Add(3, []byte{3}, 8)
+	if _, found := store.Get(1); found {
+		t.Errorf("Expected sequence 1 to be overwritten by 3")
+	}
+}
+
+func TestSnapshotSurvivesSlotReuse(t *testing.T) {
+	store, _ := New(2, 100)
+	store.Add(1, []byte{1}, 8)
+	store.Ack(1)
+	snapshot, _ := store.Get(1)
+	baseline, _ := store.Baseline()
+	store.Add(2, []byte{2}, 8)
+	store.Add(3, []byte{3, 3}, 16)
+	for _, s := range []*Snapshot{snapshot, baseline} {
+		if s.Sequence != 1 || s.BitCount != 8 || s.Octets[0] != 1 {
+			t.Errorf("Expected snapshot 1 to be unchanged but got %+v", s)
+		}
+	}
+}
+
+func TestStoreRejectsOldSequence(t *testing.T) {
+	store, _ := New(4, 4)
+	store.Add(10, []byte{1}, 8)
+	if store.Add(10, []byte{1}, 8) == nil || store.Add(9, []byte{1}, 8) == nil {
+		t.Errorf("Expected error for sequences that are not newer")
+	}
+	if store.Add(11, []byte{1}, 9) == nil {
+		t.Errorf("Expected error for bit count larger than the octets")
+	}
+}
+
+func TestBaselineFallback(t *testing.T) {
+	store, _ := New(16, 3)
+	if _, found := store.Baseline(); found {
+		t.Errorf("Expected no baseline before the first ack")
+	}
+	store.Add(1, []byte{1}, 8)
+	store.Ack(1)
+	if b, found := store.Baseline(); !found || b.Sequence != 1 {
+		t.Errorf("Expected baseline 1")
+	}
+	store.Ack(0)
+	if b, _ := store.Baseline(); b.Sequence != 1 {
+		t.Errorf("Expected older ack to be ignored")
+	}
+	for sequence := Sequence(2); sequence <= 5; sequence++ {
+		store.Add(sequence, []byte{byte(sequence)}, 8)
+	}
+	if _, found := store.Baseline(); found {
+		t.Errorf("Expected acked baseline to be expired")
+	}
+}
+
+type state struct {
+	X     int32 `brook:"range=-1000..1000"`
+	Y     int32 `brook:"range=-1000..1000"`
+	Score uint16
+}
+
+func serialize(t *testing.T, s *state) ([]byte, uint) {
+	out := outbitstream.New(64)
+	err := delta.Marshal(out, s, &state{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out.Octets(), out.Tell()
+}
+
+func TestDeltaAgainstStoredBaseline(t *testing.T) {
+	server, _ := New(32, 16)
+	client, _ := New(32, 16)
+
+	send := func(sequence Sequence, s *state) []byte {
+		octets, bitCount := serialize(t, s)
+		server.Add(sequence, octets, bitCount)
+
+		out := outbitstream.New(64)
+		out.WriteUint16(uint16(sequence))
+		baseline, isDelta := server.Baseline()
+		WriteReference(out, baseline)
+		previous := &state{}
+		if isDelta {
+			delta.Unmarshal(baseline.InStream(), previous, &state{})
+		}
+		delta.Marshal(out, s, previous)
+		return out.Octets()
+	}
+
+	receive := func(packet []byte) state {
+		in := inbitstream.New(packet, uint(len(packet))*8)
+		sequence, _ := in.ReadUint16()
+		baselineSequence, isDelta, err := ReadReference(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		previous := &state{}
+		if isDelta {
+			baseline, found := client.Get(baselineSequence)
+			if !found {
+				t.Fatalf("Client is missing baseline %v", baselineSequence)
+			}
+			delta.Unmarshal(baseline.InStream(), previous, &state{})
+		}
+		var s state
+		delta.Unmarshal(in, &s, previous)
+		octets, bitCount := serialize(t, &s)
+		client.Add(Sequence(sequence), octets, bitCount)
+		return s
+	}
+
+	first := state{X: 10, Y: 20, Score: 1}
+	if got := receive(send(1, &first)); got != first {
+		t.Errorf("Expected %+v but got %+v", first, got)
+	}
+	server.Ack(1)
+
+	second := state{X: 11, Y: 20, Score: 1}
+	packet := send(2, &second)
+	if got := receive(packet); got != second {
+		t.Errorf("Expected %+v but got %+v", second, got)
+	}
+	// 16 bit sequence, 17 bit reference, three changed bits and 11 bits for X
+	if len(packet) != 6 {
+		t.Errorf("Expected a 6 octet delta packet but got %v", len(packet))
+	}
+}
@@ -0,0 +1,54 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package baseline ...
+package baseline
+
+import (
+	"github.com/piot/brook-go/src/inbitstream"
+	"github.com/piot/brook-go/src/outbitstream"
+)
+
+// WriteReference : Writes which baseline the following delta is relative to.
+// A nil baseline means that full state follows
+func WriteReference(out outbitstream.OutBitStream, baseline *Snapshot) error {
+	isDelta := baseline != nil
+	deltaErr := outbitstream.WriteBool(out, isDelta)
+	if deltaErr != nil || !isDelta {
+		return deltaErr
+	}
+	return out.WriteUint16(uint16(baseline.Sequence))
+}
+
+// ReadReference : Reads a reference written by WriteReference. Returns false if full state follows
+func ReadReference(in inbitstream.InBitStream) (Sequence, bool, error) {
+	isDelta, deltaErr := inbitstream.ReadBool(in)
+	if deltaErr != nil || !isDelta {
+		return 0, false, deltaErr
+	}
+	sequence, sequenceErr := in.ReadUint16()
+	return Sequence(sequence), true, sequenceErr
+}
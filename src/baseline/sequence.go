@@ -0,0 +1,46 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package baseline keeps the serialized snapshots that delta encoding is relative to
+package baseline
+
+// Sequence : Snapshot sequence number that wraps around after 0xffff
+type Sequence uint16
+
+// Next : The sequence after s
+func (s Sequence) Next() Sequence {
+	return s + 1
+}
+
+// Distance : How many sequences s is ahead of other, negative if it is behind. Handles wrap around
+func (s Sequence) Distance(other Sequence) int {
+	return int(int16(s - other))
+}
+
+// IsNewerThan : Reports if s comes after other, handling wrap around
+func (s Sequence) IsNewerThan(other Sequence) bool {
+	return s.Distance(other) > 0
+}
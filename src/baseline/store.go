@@ -0,0 +1,144 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package baseline ...
+package baseline
+
+import (
+	"fmt"
+
+	"github.com/piot/brook-go/src/inbitstream"
+)
+
+// Snapshot : A serialized snapshot
+type Snapshot struct {
+	Sequence Sequence
+	Octets   []byte
+	BitCount uint
+}
+
+// InStream : Stream for reading the snapshot from the start
+func (s *Snapshot) InStream() *inbitstream.InBitStreamImpl {
+	return inbitstream.New(s.Octets, s.BitCount)
+}
+
+type entry struct {
+	valid    bool
+	snapshot Snapshot
+}
+
+// Store : Ring buffer of the latest snapshots for one connection, keyed by sequence
+type Store struct {
+	entries   []entry
+	maxAge    int
+	latest    Sequence
+	hasLatest bool
+	acked     Sequence
+	hasAcked  bool
+}
+
+// New : Creates a store holding up to capacity snapshots. Snapshots more than maxAge sequences
+// behind the latest are expired
+func New(capacity int, maxAge int) (*Store, error) {
+	if capacity < 1 {
+		return nil, fmt.Errorf("baseline: capacity must be at least 1")
+	}
+	if maxAge < 0 || maxAge >= 0x8000 {
+		return nil, fmt.Errorf("baseline: max age must be 0..32767")
+	}
+	return &Store{entries: make([]entry, capacity), maxAge: maxAge}, nil
+}
+
+func (s *Store) slot(sequence Sequence) *entry {
+	return &s.entries[int(sequence)%len(s.entries)]
+}
+
+// Add : Stores a copy of a serialized snapshot. The sequence must be newer than the previously added one
+func (s *Store) Add(sequence Sequence, octets []byte, bitCount uint) error {
+	if s.hasLatest && !sequence.IsNewerThan(s.latest) {
+		return fmt.Errorf("baseline: sequence %v is not newer than %v", sequence, s.latest)
+	}
+	if uint64(bitCount) > uint64(len(octets))*8 {
+		return fmt.Errorf("baseline: bit count %v does not fit in %v octets", bitCount, len(octets))
+	}
+	octetCount := (bitCount + 7) / 8
+	copied := make([]byte, octetCount)
+	copy(copied, octets)
+	*s.slot(sequence) = entry{valid: true, snapshot: Snapshot{Sequence: sequence, Octets: copied, BitCount: bitCount}}
+	s.latest = sequence
+	s.hasLatest = true
+	return nil
+}
+
+// Latest : The most recently added sequence
+func (s *Store) Latest() (Sequence, bool) {
+	return s.latest, s.hasLatest
+}
+
+// Get : Finds the snapshot with the sequence, if it is stored and not expired. Returns a copy,
+// so it stays the same when a later Add reuses the slot
+func (s *Store) Get(sequence Sequence) (*Snapshot, bool) {
+	if !s.hasLatest {
+		return nil, false
+	}
+	age := s.latest.Distance(sequence)
+	if age < 0 || age > s.maxAge {
+		return nil, false
+	}
+	e := s.slot(sequence)
+	if !e.valid || e.snapshot.Sequence != sequence {
+		return nil, false
+	}
+	copied := e.snapshot
+	return &copied, true
+}
+
+// Ack : Records that the client has received the snapshot. Acks older than the current one are ignored
+func (s *Store) Ack(sequence Sequence) {
+	if s.hasAcked && !sequence.IsNewerThan(s.acked) {
+		return
+	}
+	s.acked = sequence
+	s.hasAcked = true
+}
+
+// Baseline : The snapshot the next delta should be relative to. Returns false if the client has not
+// acked anything yet or the acked snapshot has expired, then full state must be sent. Returns a copy like Get
+func (s *Store) Baseline() (*Snapshot, bool) {
+	if !s.hasAcked {
+		return nil, false
+	}
+	return s.Get(s.acked)
+}
+
+// Clear : Removes all snapshots and acks, e.g. when a client reconnects
+func (s *Store) Clear() {
+	for i := range s.entries {
+		s.entries[i] = entry{}
+	}
+	s.hasLatest = false
+	s.hasAcked = false
+}
@@ -27,6 +27,7 @@ SOFTWARE.
 package bits
 
 import (
+	"encoding/binary"
 	"strconv"
 	"strings"
 )
@@ -79,3 +80,28 @@ func ToString(octets []byte) string {
 
 	return s
 }
+
+// CopyShifted : Fills target with the octets that start bitOffset (0..7) bits into source.
+// Octets missing at the end of source are read as zero
+func CopyShifted(target []byte, source []byte, bitOffset uint) {
+	if bitOffset == 0 {
+		copy(target, source)
+		return
+	}
+	i := 0
+	for ; i+8 < len(source) && i+8 <= len(target); i += 8 {
+		word := binary.BigEndian.Uint64(source[i:])<<bitOffset | uint64(source[i+8])>>(8-bitOffset)
+		binary.BigEndian.PutUint64(target[i:], word)
+	}
+	for ; i < len(target); i++ {
+		current := byte(0)
+		if i < len(source) {
+			current = source[i]
+		}
+		next := byte(0)
+		if i+1 < len(source) {
+			next = source[i+1]
+		}
+		target[i] = current<<bitOffset | next>>(8-bitOffset)
+	}
+}
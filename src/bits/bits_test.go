@@ -43,3 +43,15 @@ func TestBitString(t *testing.T) {
 		t.Errorf("Wrong encoding %v", octets)
 	}
 }
+
+func TestCopyShifted(t *testing.T) {
+	source := []byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef, 0x13, 0x57, 0x9b}
+	target := make([]byte, len(source))
+	CopyShifted(target, source, 4)
+	expected := []byte{0x12, 0x34, 0x56, 0x78, 0x9a, 0xbc, 0xde, 0xf1, 0x35, 0x79, 0xb0}
+	for i := range expected {
+		if target[i] != expected[i] {
+			t.Errorf("Octet %v: expected %02x but got %02x", i, expected[i], target[i])
+		}
+	}
+}
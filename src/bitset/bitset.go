@@ -0,0 +1,108 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package bitset ...
+package bitset
+
+import (
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// Bitset : Fixed length set of bits, e.g. dirty masks or button states
+type Bitset struct {
+	words  []uint32
+	length uint
+}
+
+// New : Creates a bitset with all bits cleared
+func New(length uint) *Bitset {
+	return &Bitset{words: make([]uint32, (length+31)/32), length: length}
+}
+
+func wordMask(index uint) uint32 {
+	return uint32(0x80000000) >> (index % 32)
+}
+
+// Len : Number of bits in the set
+func (b *Bitset) Len() uint {
+	return b.length
+}
+
+// Set : Sets the bit at index
+func (b *Bitset) Set(index uint) {
+	if index >= b.length {
+		panic(fmt.Sprintf("bitset: index %v out of range %v", index, b.length))
+	}
+	b.words[index/32] |= wordMask(index)
+}
+
+// Clear : Clears the bit at index
+func (b *Bitset) Clear(index uint) {
+	if index >= b.length {
+		panic(fmt.Sprintf("bitset: index %v out of range %v", index, b.length))
+	}
+	b.words[index/32] &^= wordMask(index)
+}
+
+// Test : Checks if the bit at index is set
+func (b *Bitset) Test(index uint) bool {
+	if index >= b.length {
+		return false
+	}
+	return b.words[index/32]&wordMask(index) != 0
+}
+
+// Count : Number of set bits
+func (b *Bitset) Count() uint {
+	count := 0
+	for _, word := range b.words {
+		count += bits.OnesCount32(word)
+	}
+	return uint(count)
+}
+
+// ClearAll : Clears all bits
+func (b *Bitset) ClearAll() {
+	for i := range b.words {
+		b.words[i] = 0
+	}
+}
+
+func (b *Bitset) String() string {
+	var builder strings.Builder
+	builder.WriteString("[bitset ")
+	for i := uint(0); i < b.length; i++ {
+		if b.Test(i) {
+			builder.WriteByte('1')
+		} else {
+			builder.WriteByte('0')
+		}
+	}
+	builder.WriteString("]")
+	return builder.String()
+}
@@ -0,0 +1,100 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package bitset
+
+import (
+	"testing"
+
+	"github.com/piot/brook-go/src/inbitstream"
+	"github.com/piot/brook-go/src/outbitstream"
+)
+
+func TestSetClearCount(t *testing.T) {
+	b := New(70)
+	b.Set(0)
+	b.Set(33)
+	b.Set(69)
+	b.Clear(33)
+	if !b.Test(0) || b.Test(33) || !b.Test(69) {
+		t.Errorf("Wrong bits %v", b)
+	}
+	if b.Count() != 2 {
+		t.Errorf("Expected count 2 but got %v", b.Count())
+	}
+}
+
+func roundTrip(t *testing.T, b *Bitset) uint {
+	out := outbitstream.New(64)
+	writeErr := WriteBitset(out, b)
+	if writeErr != nil {
+		t.Fatal(writeErr)
+	}
+	in := inbitstream.New(out.Octets(), out.Tell())
+	readBitset, readErr := ReadBitset(in, b.Len())
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	if readBitset.String() != b.String() {
+		t.Errorf("Expected %v but got %v", b, readBitset)
+	}
+	return out.Tell()
+}
+
+func TestDense(t *testing.T) {
+	b := New(70)
+	for i := uint(0); i < 70; i += 3 {
+		b.Set(i)
+	}
+	bitCount := roundTrip(t, b)
+	if bitCount != 1+70 {
+		t.Errorf("Expected dense encoding but wrote %v bits", bitCount)
+	}
+}
+
+func TestSparse(t *testing.T) {
+	b := New(70)
+	b.Set(2)
+	b.Set(65)
+	bitCount := roundTrip(t, b)
+	if bitCount != 1+7+2*7 {
+		t.Errorf("Expected sparse encoding but wrote %v bits", bitCount)
+	}
+}
+
+func TestTruncatedStream(t *testing.T) {
+	dense := New(70)
+	for i := uint(0); i < 70; i += 2 {
+		dense.Set(i)
+	}
+	out := outbitstream.New(64)
+	WriteBitset(out, dense)
+	in := inbitstream.New(out.Octets(), out.Tell()-10)
+	b, err := ReadBitset(in, 70)
+	if err == nil || b != nil {
+		t.Errorf("Expected error and no bitset for truncated stream, got %v %v", b, err)
+	}
+}
@@ -0,0 +1,157 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package bitset ...
+package bitset
+
+import (
+	"fmt"
+	"math/bits"
+
+	"github.com/piot/brook-go/src/inbitstream"
+	"github.com/piot/brook-go/src/outbitstream"
+)
+
+func countBitCount(length uint) uint {
+	return uint(bits.Len(length))
+}
+
+func indexBitCount(length uint) uint {
+	if length == 0 {
+		return 0
+	}
+	return uint(bits.Len(length - 1))
+}
+
+func sparseBitCount(length uint, setCount uint) uint {
+	return countBitCount(length) + setCount*indexBitCount(length)
+}
+
+// WriteBitset : Writes a mode bit followed by either all bits or, when that is smaller, the indices of the set bits
+func WriteBitset(targetStream outbitstream.OutBitStream, b *Bitset) error {
+	setCount := b.Count()
+	useSparse := sparseBitCount(b.length, setCount) < b.length
+	modeErr := outbitstream.WriteBool(targetStream, useSparse)
+	if modeErr != nil {
+		return modeErr
+	}
+	if useSparse {
+		return writeSparse(targetStream, b, setCount)
+	}
+	return writeDense(targetStream, b)
+}
+
+func writeDense(targetStream outbitstream.OutBitStream, b *Bitset) error {
+	remaining := b.length
+	for _, word := range b.words {
+		count := uint(32)
+		if remaining < 32 {
+			count = remaining
+		}
+		remaining -= count
+		writeErr := targetStream.WriteBits(word>>(32-count), count)
+		if writeErr != nil {
+			return writeErr
+		}
+	}
+	return nil
+}
+
+func writeSparse(targetStream outbitstream.OutBitStream, b *Bitset, setCount uint) error {
+	countErr := targetStream.WriteBits(uint32(setCount), countBitCount(b.length))
+	if countErr != nil {
+		return countErr
+	}
+	indexBits := indexBitCount(b.length)
+	for i := uint(0); i < b.length; i++ {
+		if !b.Test(i) {
+			continue
+		}
+		indexErr := targetStream.WriteBits(uint32(i), indexBits)
+		if indexErr != nil {
+			return indexErr
+		}
+	}
+	return nil
+}
+
+// ReadBitset : Reads a bitset with the specified length written by WriteBitset
+func ReadBitset(sourceStream inbitstream.InBitStream, length uint) (*Bitset, error) {
+	useSparse, modeErr := inbitstream.ReadBool(sourceStream)
+	if modeErr != nil {
+		return nil, modeErr
+	}
+	b := New(length)
+	var readErr error
+	if useSparse {
+		readErr = readSparse(sourceStream, b)
+	} else {
+		readErr = readDense(sourceStream, b)
+	}
+	if readErr != nil {
+		return nil, readErr
+	}
+	return b, nil
+}
+
+func readDense(sourceStream inbitstream.InBitStream, b *Bitset) error {
+	remaining := b.length
+	for i := range b.words {
+		count := uint(32)
+		if remaining < 32 {
+			count = remaining
+		}
+		remaining -= count
+		word, readErr := sourceStream.ReadBits(count)
+		if readErr != nil {
+			return readErr
+		}
+		b.words[i] = word << (32 - count)
+	}
+	return nil
+}
+
+func readSparse(sourceStream inbitstream.InBitStream, b *Bitset) error {
+	setCount, countErr := sourceStream.ReadBits(countBitCount(b.length))
+	if countErr != nil {
+		return countErr
+	}
+	if uint(setCount) > b.length {
+		return fmt.Errorf("bitset: set count %v exceeds length %v", setCount, b.length)
+	}
+	indexBits := indexBitCount(b.length)
+	for i := uint32(0); i < setCount; i++ {
+		index, indexErr := sourceStream.ReadBits(indexBits)
+		if indexErr != nil {
+			return indexErr
+		}
+		if uint(index) >= b.length {
+			return fmt.Errorf("bitset: index %v out of range %v", index, b.length)
+		}
+		b.Set(uint(index))
+	}
+	return nil
+}
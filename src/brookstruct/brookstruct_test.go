@@ -0,0 +1,311 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package brookstruct
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/piot/brook-go/src/bits"
+	"github.com/piot/brook-go/src/inbitstream"
+	"github.com/piot/brook-go/src/outbitstream"
+)
+
+type vector struct {
+	X float32 `brook:"quant=-10..10,bits=12"`
+	Y float32 `brook:"quant=-10..10,bits=12"`
+}
+
+type player struct {
+	ID       uint16
+	Health   uint8 `brook:"bits=7"`
+	Offset   int32 `brook:"range=-100..100"`
+	Drift    int8  `brook:"bits=5"`
+	Alive    bool
+	Name     string `brook:"max=16"`
+	Position vector
+	Items    []uint8 `brook:"bits=4,max=8"`
+	Slots    [2]int16
+	Target   *vector
+	Score    int64
+	Speed    float64
+	Cache    int `brook:"skip"`
+	internal int
+}
+
+func TestRoundTrip(t *testing.T) {
+	p := player{ID: 0xcafe, Health: 99, Offset: -42, Drift: -9, Alive: true, Name: "brook",
+		Position: vector{X: 1.5, Y: -9.25}, Items: []uint8{1, 15, 7}, Slots: [2]int16{-300, 300},
+		Target: &vector{X: 10, Y: -10}, Score: -1, Speed: math.Pi, Cache: 9, internal: 3}
+
+	out := outbitstream.New(256)
+	writeErr := Marshal(out, &p)
+	if writeErr != nil {
+		t.Fatal(writeErr)
+	}
+
+	var readPlayer player
+	in := inbitstream.New(out.Octets(), out.Tell())
+	readErr := Unmarshal(in, &readPlayer)
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	if !in.IsEOF() {
+		t.Errorf("Expected all bits to be read")
+	}
+
+	if math.Abs(float64(readPlayer.Position.Y-p.Position.Y)) > 0.01 {
+		t.Errorf("Quantized value too far off %v", readPlayer.Position.Y)
+	}
+	readPlayer.Position = p.Position
+	p.Cache = 0
+	p.internal = 0
+	if !reflect.DeepEqual(p, readPlayer) {
+		t.Errorf("Expected %+v but got %+v", p, readPlayer)
+	}
+}
+
+type small struct {
+	A uint8 `brook:"bits=3"`
+	B int16 `brook:"range=-2..5"`
+	C bool
+}
+
+func TestExactBits(t *testing.T) {
+	out := outbitstream.New(64)
+	Marshal(out, small{A: 5, B: -1, C: true})
+	out.Close()
+
+	expected, expectedBitCount := bits.FromString("101 001 1")
+	if out.Tell() != expectedBitCount || out.Octets()[0] != expected[0] {
+		t.Errorf("Expected %v but got %v", bits.ToString(expected), bits.ToString(out.Octets()))
+	}
+}
+
+func TestCodecIsCached(t *testing.T) {
+	first, _ := CodecFor(reflect.TypeOf(small{}))
+	second, _ := CodecFor(reflect.TypeOf(small{}))
+	if first != second {
+		t.Errorf("Expected the same codec")
+	}
+}
+
+type badTag struct {
+	Name string `brook:"bits=3"`
+}
+
+func TestBadTag(t *testing.T) {
+	err := Marshal(outbitstream.New(64), badTag{})
+	if err == nil {
+		t.Errorf("Expected error")
+	}
+	_, parseErr := ParseTag("range=5..1")
+	if parseErr == nil {
+		t.Errorf("Expected error")
+	}
+}
+
+func TestPlanDoesNotFitField(t *testing.T) {
+	invalid := []struct {
+		kind ScalarKind
+		tag  string
+	}{
+		{KindUint8, "bits=12"},
+		{KindInt16, "bits=17"},
+		{KindInt8, "range=-1000..1000"},
+		{KindInt8, "range=-200..-100"},
+		{KindUint16, "range=-1..10"},
+		{KindUint32, "range=0..4294967296"},
+	}
+	for _, test := range invalid {
+		options, _ := ParseTag(test.tag)
+		if _, err := PlanScalar(test.kind, options); err == nil {
+			t.Errorf("Expected error for %v on kind %v", test.tag, test.kind)
+		}
+	}
+
+	valid := []struct {
+		kind ScalarKind
+		tag  string
+	}{
+		{KindUint8, "bits=8"},
+		{KindInt8, "bits=8"},
+		{KindInt8, "range=-128..127"},
+		{KindUint32, "range=0..4294967295"},
+		{KindInt64, "range=-1000..1000"},
+	}
+	for _, test := range valid {
+		options, _ := ParseTag(test.tag)
+		if _, err := PlanScalar(test.kind, options); err != nil {
+			t.Errorf("Expected %v on kind %v to be valid: %v", test.tag, test.kind, err)
+		}
+	}
+}
+
+type node struct {
+	Value uint8
+	Next  *node
+}
+
+func TestRecursive(t *testing.T) {
+	out := outbitstream.New(64)
+	writeErr := Marshal(out, node{Value: 1, Next: &node{Value: 2}})
+	if writeErr != nil {
+		t.Fatal(writeErr)
+	}
+	var readNode node
+	readErr := Unmarshal(inbitstream.New(out.Octets(), out.Tell()), &readNode)
+	if readErr != nil || readNode.Next == nil || readNode.Next.Value != 2 {
+		t.Errorf("Wrong node %+v %v", readNode, readErr)
+	}
+}
+
+type brokenParent struct {
+	Child *childOfBroken
+	Wide  uint8 `brook:"bits=12"`
+}
+
+type childOfBroken struct {
+	Parent *brokenParent
+	Value  uint8
+}
+
+func TestFailedBuildIsNotCached(t *testing.T) {
+	if _, err := CodecFor(reflect.TypeOf(brokenParent{})); err == nil {
+		t.Fatalf("Expected error for too wide field")
+	}
+	if _, err := CodecFor(reflect.TypeOf(childOfBroken{})); err == nil {
+		t.Errorf("Expected error, the child refers to the broken parent")
+	}
+}
+
+type platformSized struct {
+	U uint
+	I int
+}
+
+func TestIntAndUintUseSixtyFourBits(t *testing.T) {
+	v := platformSized{U: 1 << 40, I: -(1 << 40)}
+	out := outbitstream.New(64)
+	writeErr := Marshal(out, v)
+	if writeErr != nil {
+		t.Fatal(writeErr)
+	}
+	if out.Tell() != 128 {
+		t.Errorf("Expected 128 bits but got %v", out.Tell())
+	}
+	var readValue platformSized
+	readErr := Unmarshal(inbitstream.New(out.Octets(), out.Tell()), &readValue)
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	if readValue != v {
+		t.Errorf("Expected %+v but got %+v", v, readValue)
+	}
+}
+
+type tooLargeBits struct {
+	A uint8 `brook:"bits=7"`
+}
+
+type tooSmallSigned struct {
+	A int8 `brook:"bits=4"`
+}
+
+type minimumInt8 struct {
+	A int8
+}
+
+type minimumInt16 struct {
+	A int16
+}
+
+type minimumInt32 struct {
+	A int32
+}
+
+type tooLongString struct {
+	Name string `brook:"max=16"`
+}
+
+func TestValueDoesNotFit(t *testing.T) {
+	values := []interface{}{
+		tooLargeBits{A: 200},
+		tooSmallSigned{A: -8},
+		minimumInt8{A: math.MinInt8},
+		minimumInt16{A: math.MinInt16},
+		minimumInt32{A: math.MinInt32},
+		tooLongString{Name: "twenty octets long.."},
+	}
+	for _, v := range values {
+		err := Marshal(outbitstream.New(64), v)
+		if !errors.Is(err, outbitstream.ErrOverflow) {
+			t.Errorf("%+v: expected overflow but got %v", v, err)
+		}
+	}
+}
+
+func TestLargestValuesFit(t *testing.T) {
+	values := []interface{}{
+		&tooLargeBits{A: 127},
+		&tooSmallSigned{A: -7},
+		&minimumInt8{A: math.MinInt8 + 1},
+		&minimumInt16{A: math.MinInt16 + 1},
+		&minimumInt32{A: math.MinInt32 + 1},
+		&tooLongString{Name: "sixteen octets.."},
+	}
+	for _, v := range values {
+		out := outbitstream.New(64)
+		writeErr := Marshal(out, v)
+		if writeErr != nil {
+			t.Fatal(writeErr)
+		}
+		readValue := reflect.New(reflect.TypeOf(v).Elem())
+		readErr := Unmarshal(inbitstream.New(out.Octets(), out.Tell()), readValue.Interface())
+		if readErr != nil {
+			t.Fatal(readErr)
+		}
+		if !reflect.DeepEqual(readValue.Interface(), v) {
+			t.Errorf("Expected %+v but got %+v", v, readValue.Interface())
+		}
+	}
+}
+
+func TestReadValueOutOfBounds(t *testing.T) {
+	out := outbitstream.New(64)
+	out.WriteBits(7, 3)
+	out.WriteBits(30, 5)
+	in := inbitstream.New(out.Octets(), out.Tell())
+	if _, err := ReadRange(in, 10, 15); !errors.Is(err, inbitstream.ErrOverflow) {
+		t.Errorf("Expected overflow for range but got %v", err)
+	}
+	if _, err := ReadLength(in, 5, 20); !errors.Is(err, inbitstream.ErrOverflow) {
+		t.Errorf("Expected overflow for length but got %v", err)
+	}
+}
@@ -0,0 +1,481 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package brookstruct serializes tagged Go structs to bit streams using reflection
+package brookstruct
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sync"
+
+	"github.com/piot/brook-go/src/inbitstream"
+	"github.com/piot/brook-go/src/outbitstream"
+)
+
+type valueCodec struct {
+	write func(out outbitstream.OutBitStream, v reflect.Value) error
+	read  func(in inbitstream.InBitStream, v reflect.Value) error
+}
+
+type fieldCodec struct {
+	name  string
+	index int
+	codec valueCodec
+}
+
+// StructCodec : Cached encoder and decoder for one struct type
+type StructCodec struct {
+	structType reflect.Type
+	fields     []fieldCodec
+}
+
+var (
+	buildLock sync.Mutex
+	// building : Codecs created during the current CodecFor call. Recursive types can point to codecs
+	// that are not done yet, so they are only cached in built when the whole build succeeds
+	building = map[reflect.Type]*StructCodec{}
+	built    sync.Map
+)
+
+// CodecFor : Returns the cached codec for the struct type, building it the first time
+func CodecFor(structType reflect.Type) (*StructCodec, error) {
+	if codec, found := built.Load(structType); found {
+		return codec.(*StructCodec), nil
+	}
+	buildLock.Lock()
+	defer buildLock.Unlock()
+	codec, buildErr := codecForLocked(structType)
+	if buildErr == nil {
+		for builtType, builtCodec := range building {
+			built.Store(builtType, builtCodec)
+		}
+	}
+	building = map[reflect.Type]*StructCodec{}
+	return codec, buildErr
+}
+
+func codecForLocked(structType reflect.Type) (*StructCodec, error) {
+	if codec, found := built.Load(structType); found {
+		return codec.(*StructCodec), nil
+	}
+	if codec, found := building[structType]; found {
+		return codec, nil
+	}
+	if structType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("brookstruct: %v is not a struct", structType)
+	}
+
+	codec := &StructCodec{structType: structType}
+	building[structType] = codec
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		options, tagErr := ParseTag(field.Tag.Get("brook"))
+		if tagErr != nil {
+			return nil, fmt.Errorf("%v.%v: %w", structType, field.Name, tagErr)
+		}
+		if options.Skip {
+			continue
+		}
+		fieldValueCodec, codecErr := codecForType(field.Type, options)
+		if codecErr != nil {
+			return nil, fmt.Errorf("%v.%v: %w", structType, field.Name, codecErr)
+		}
+		codec.fields = append(codec.fields, fieldCodec{name: field.Name, index: i, codec: fieldValueCodec})
+	}
+	return codec, nil
+}
+
+func scalarKindOf(kind reflect.Kind) (ScalarKind, bool) {
+	switch kind {
+	case reflect.Bool:
+		return KindBool, true
+	case reflect.Uint8:
+		return KindUint8, true
+	case reflect.Uint16:
+		return KindUint16, true
+	case reflect.Uint32:
+		return KindUint32, true
+	case reflect.Uint64:
+		return KindUint64, true
+	case reflect.Uint:
+		return KindUint, true
+	case reflect.Int8:
+		return KindInt8, true
+	case reflect.Int16:
+		return KindInt16, true
+	case reflect.Int32:
+		return KindInt32, true
+	case reflect.Int64:
+		return KindInt64, true
+	case reflect.Int:
+		return KindInt, true
+	case reflect.Float32:
+		return KindFloat32, true
+	case reflect.Float64:
+		return KindFloat64, true
+	case reflect.String:
+		return KindString, true
+	}
+	return 0, false
+}
+
+func codecForType(t reflect.Type, options Options) (valueCodec, error) {
+	if scalarKind, isScalar := scalarKindOf(t.Kind()); isScalar {
+		plan, planErr := PlanScalar(scalarKind, options)
+		if planErr != nil {
+			return valueCodec{}, planErr
+		}
+		return scalarCodec(plan), nil
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		structCodec, structErr := codecForLocked(t)
+		if structErr != nil {
+			return valueCodec{}, structErr
+		}
+		return valueCodec{write: structCodec.write, read: structCodec.read}, nil
+	case reflect.Ptr:
+		elemCodec, elemErr := codecForType(t.Elem(), options)
+		if elemErr != nil {
+			return valueCodec{}, elemErr
+		}
+		return pointerCodec(t, elemCodec), nil
+	case reflect.Slice:
+		elemOptions := options
+		elemOptions.Max = 0
+		elemCodec, elemErr := codecForType(t.Elem(), elemOptions)
+		if elemErr != nil {
+			return valueCodec{}, elemErr
+		}
+		lengthBitCount, maxLength := LengthPrefix(options)
+		return sliceCodec(t, elemCodec, lengthBitCount, maxLength), nil
+	case reflect.Array:
+		elemCodec, elemErr := codecForType(t.Elem(), options)
+		if elemErr != nil {
+			return valueCodec{}, elemErr
+		}
+		return arrayCodec(elemCodec), nil
+	}
+	return valueCodec{}, fmt.Errorf("brookstruct: unsupported type %v", t)
+}
+
+func pointerCodec(t reflect.Type, elemCodec valueCodec) valueCodec {
+	return valueCodec{
+		write: func(out outbitstream.OutBitStream, v reflect.Value) error {
+			presenceErr := outbitstream.WriteBool(out, !v.IsNil())
+			if presenceErr != nil || v.IsNil() {
+				return presenceErr
+			}
+			return elemCodec.write(out, v.Elem())
+		},
+		read: func(in inbitstream.InBitStream, v reflect.Value) error {
+			present, presenceErr := inbitstream.ReadBool(in)
+			if presenceErr != nil {
+				return presenceErr
+			}
+			if !present {
+				v.Set(reflect.Zero(t))
+				return nil
+			}
+			target := reflect.New(t.Elem())
+			readErr := elemCodec.read(in, target.Elem())
+			if readErr != nil {
+				return readErr
+			}
+			v.Set(target)
+			return nil
+		},
+	}
+}
+
+func sliceCodec(t reflect.Type, elemCodec valueCodec, lengthBitCount uint, maxLength uint) valueCodec {
+	return valueCodec{
+		write: func(out outbitstream.OutBitStream, v reflect.Value) error {
+			countErr := WriteLength(out, v.Len(), lengthBitCount, maxLength)
+			if countErr != nil {
+				return countErr
+			}
+			for i := 0; i < v.Len(); i++ {
+				elemErr := elemCodec.write(out, v.Index(i))
+				if elemErr != nil {
+					return elemErr
+				}
+			}
+			return nil
+		},
+		read: func(in inbitstream.InBitStream, v reflect.Value) error {
+			count, countErr := ReadLength(in, lengthBitCount, maxLength)
+			if countErr != nil {
+				return countErr
+			}
+			slice := reflect.MakeSlice(t, count, count)
+			for i := 0; i < count; i++ {
+				elemErr := elemCodec.read(in, slice.Index(i))
+				if elemErr != nil {
+					return elemErr
+				}
+			}
+			v.Set(slice)
+			return nil
+		},
+	}
+}
+
+func arrayCodec(elemCodec valueCodec) valueCodec {
+	return valueCodec{
+		write: func(out outbitstream.OutBitStream, v reflect.Value) error {
+			for i := 0; i < v.Len(); i++ {
+				elemErr := elemCodec.write(out, v.Index(i))
+				if elemErr != nil {
+					return elemErr
+				}
+			}
+			return nil
+		},
+		read: func(in inbitstream.InBitStream, v reflect.Value) error {
+			for i := 0; i < v.Len(); i++ {
+				elemErr := elemCodec.read(in, v.Index(i))
+				if elemErr != nil {
+					return elemErr
+				}
+			}
+			return nil
+		},
+	}
+}
+
+func integerOf(v reflect.Value) int64 {
+	if v.CanInt() {
+		return v.Int()
+	}
+	return int64(v.Uint())
+}
+
+func setInteger(v reflect.Value, i int64) {
+	if v.CanInt() {
+		v.SetInt(i)
+	} else {
+		v.SetUint(uint64(i))
+	}
+}
+
+func scalarCodec(plan Plan) valueCodec {
+	return valueCodec{
+		write: func(out outbitstream.OutBitStream, v reflect.Value) error {
+			return writeScalar(out, plan, v)
+		},
+		read: func(in inbitstream.InBitStream, v reflect.Value) error {
+			return readScalar(in, plan, v)
+		},
+	}
+}
+
+func writeScalar(out outbitstream.OutBitStream, plan Plan, v reflect.Value) error {
+	switch plan.Method {
+	case MethodBool:
+		return outbitstream.WriteBool(out, v.Bool())
+	case MethodBits:
+		return WriteUnsignedBits(out, v.Uint(), plan.Bits)
+	case MethodSignedBits:
+		return WriteSignedBits(out, v.Int(), plan.Bits)
+	case MethodRange:
+		return WriteRange(out, integerOf(v), plan.Min, plan.Max)
+	case MethodQuant:
+		return WriteQuantized(out, v.Float(), plan.QuantMin, plan.QuantMax, plan.Bits)
+	case MethodUint8:
+		return out.WriteUint8(uint8(v.Uint()))
+	case MethodUint16:
+		return out.WriteUint16(uint16(v.Uint()))
+	case MethodUint32:
+		return out.WriteUint32(uint32(v.Uint()))
+	case MethodUint64:
+		return out.WriteUint64(uint64(integerOf(v)))
+	case MethodInt16:
+		return WriteSignedBits(out, v.Int(), 16)
+	case MethodFloat32:
+		return out.WriteUint32(math.Float32bits(float32(v.Float())))
+	case MethodFloat64:
+		return out.WriteUint64(math.Float64bits(v.Float()))
+	case MethodString:
+		return WriteString(out, v.String(), plan.Bits, plan.MaxLength)
+	}
+	return fmt.Errorf("brookstruct: unknown method %v", plan.Method)
+}
+
+func readScalar(in inbitstream.InBitStream, plan Plan, v reflect.Value) error {
+	switch plan.Method {
+	case MethodBool:
+		b, err := inbitstream.ReadBool(in)
+		v.SetBool(b)
+		return err
+	case MethodBits:
+		u, err := in.ReadBits(plan.Bits)
+		v.SetUint(uint64(u))
+		return err
+	case MethodSignedBits:
+		i, err := in.ReadSignedBits(plan.Bits)
+		v.SetInt(int64(i))
+		return err
+	case MethodRange:
+		i, err := ReadRange(in, plan.Min, plan.Max)
+		setInteger(v, i)
+		return err
+	case MethodQuant:
+		f, err := ReadQuantized(in, plan.QuantMin, plan.QuantMax, plan.Bits)
+		v.SetFloat(f)
+		return err
+	case MethodUint8:
+		u, err := in.ReadUint8()
+		v.SetUint(uint64(u))
+		return err
+	case MethodUint16:
+		u, err := in.ReadUint16()
+		v.SetUint(uint64(u))
+		return err
+	case MethodUint32:
+		u, err := in.ReadUint32()
+		v.SetUint(uint64(u))
+		return err
+	case MethodUint64:
+		u, err := in.ReadUint64()
+		setInteger(v, int64(u))
+		return err
+	case MethodInt16:
+		i, err := in.ReadInt16()
+		v.SetInt(int64(i))
+		return err
+	case MethodFloat32:
+		u, err := in.ReadUint32()
+		v.SetFloat(float64(math.Float32frombits(u)))
+		return err
+	case MethodFloat64:
+		u, err := in.ReadUint64()
+		v.SetFloat(math.Float64frombits(u))
+		return err
+	case MethodString:
+		s, err := in.ReadString(plan.Bits, plan.MaxLength)
+		v.SetString(s)
+		return err
+	}
+	return fmt.Errorf("brookstruct: unknown method %v", plan.Method)
+}
+
+func (c *StructCodec) write(out outbitstream.OutBitStream, v reflect.Value) error {
+	for _, field := range c.fields {
+		fieldErr := field.codec.write(out, v.Field(field.index))
+		if fieldErr != nil {
+			return fmt.Errorf("%v.%v: %w", c.structType.Name(), field.name, fieldErr)
+		}
+	}
+	return nil
+}
+
+func (c *StructCodec) read(in inbitstream.InBitStream, v reflect.Value) error {
+	for _, field := range c.fields {
+		fieldErr := field.codec.read(in, v.Field(field.index))
+		if fieldErr != nil {
+			return fmt.Errorf("%v.%v: %w", c.structType.Name(), field.name, fieldErr)
+		}
+	}
+	return nil
+}
+
+// Marshal : Writes the struct, or pointer to struct, to the stream
+func Marshal(out outbitstream.OutBitStream, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return fmt.Errorf("brookstruct: can not marshal nil pointer")
+		}
+		rv = rv.Elem()
+	}
+	codec, codecErr := CodecFor(rv.Type())
+	if codecErr != nil {
+		return codecErr
+	}
+	return codec.write(out, rv)
+}
+
+// Unmarshal : Reads the stream into the struct that v points to
+func Unmarshal(in inbitstream.InBitStream, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("brookstruct: unmarshal needs a non-nil pointer to a struct, not %T", v)
+	}
+	codec, codecErr := CodecFor(rv.Elem().Type())
+	if codecErr != nil {
+		return codecErr
+	}
+	return codec.read(in, rv.Elem())
+}
+
+// FieldCount : Number of serialized fields, skipped and unexported fields are not counted
+func (c *StructCodec) FieldCount() int {
+	return len(c.fields)
+}
+
+// FieldName : Go name of the serialized field at index
+func (c *StructCodec) FieldName(index int) string {
+	return c.fields[index].name
+}
+
+// FieldEqual : Reports if the serialized field at index has the same value in the structs a and b
+func (c *StructCodec) FieldEqual(a reflect.Value, b reflect.Value, index int) bool {
+	fieldIndex := c.fields[index].index
+	return reflect.DeepEqual(a.Field(fieldIndex).Interface(), b.Field(fieldIndex).Interface())
+}
+
+// CopyField : Sets the serialized field at index in target to the value in source
+func (c *StructCodec) CopyField(target reflect.Value, source reflect.Value, index int) {
+	fieldIndex := c.fields[index].index
+	target.Field(fieldIndex).Set(source.Field(fieldIndex))
+}
+
+// WriteField : Writes only the serialized field at index of the struct v
+func (c *StructCodec) WriteField(out outbitstream.OutBitStream, v reflect.Value, index int) error {
+	field := c.fields[index]
+	fieldErr := field.codec.write(out, v.Field(field.index))
+	if fieldErr != nil {
+		return fmt.Errorf("%v.%v: %w", c.structType.Name(), field.name, fieldErr)
+	}
+	return nil
+}
+
+// ReadField : Reads only the serialized field at index into the struct v
+func (c *StructCodec) ReadField(in inbitstream.InBitStream, v reflect.Value, index int) error {
+	field := c.fields[index]
+	fieldErr := field.codec.read(in, v.Field(field.index))
+	if fieldErr != nil {
+		return fmt.Errorf("%v.%v: %w", c.structType.Name(), field.name, fieldErr)
+	}
+	return nil
+}
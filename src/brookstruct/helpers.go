@@ -0,0 +1,137 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package brookstruct ...
+package brookstruct
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/piot/brook-go/src/inbitstream"
+	"github.com/piot/brook-go/src/outbitstream"
+)
+
+// WriteRange : Writes v - min using the bits needed for min..max
+func WriteRange(out outbitstream.OutBitStream, v int64, min int64, max int64) error {
+	if v < min || v > max {
+		return fmt.Errorf("brookstruct: %v is outside range %v..%v", v, min, max)
+	}
+	return out.WriteBits(uint32(uint64(v-min)), RangeBitCount(min, max))
+}
+
+// ReadRange : Reads a value written by WriteRange
+func ReadRange(in inbitstream.InBitStream, min int64, max int64) (int64, error) {
+	offset, err := in.ReadBits(RangeBitCount(min, max))
+	if err != nil {
+		return 0, err
+	}
+	if uint64(offset) > uint64(max-min) {
+		return 0, &inbitstream.OverflowError{Value: uint64(offset), Max: uint64(max - min), Tell: inbitstream.TellOf(in)}
+	}
+	return min + int64(offset), nil
+}
+
+// WriteUnsignedBits : Writes v using bitCount bits. Fails if v does not fit, instead of dropping the high bits
+func WriteUnsignedBits(out outbitstream.OutBitStream, v uint64, bitCount uint) error {
+	if bitCount < 64 && v>>bitCount != 0 {
+		return &outbitstream.OverflowError{Value: v, BitCount: bitCount, Tell: out.Tell()}
+	}
+	return out.WriteBits(uint32(v), bitCount)
+}
+
+// WriteSignedBits : Writes v as a sign bit followed by the magnitude in bitCount-1 bits.
+// Fails if the magnitude does not fit, which includes the most negative value of int8, int16 and int32
+func WriteSignedBits(out outbitstream.OutBitStream, v int64, bitCount uint) error {
+	magnitude := uint64(v)
+	if v < 0 {
+		magnitude = -magnitude
+	}
+	if bitCount == 0 || magnitude>>(bitCount-1) != 0 {
+		return &outbitstream.OverflowError{Value: magnitude, BitCount: bitCount - 1, Tell: out.Tell()}
+	}
+	return out.WriteSignedBits(int32(v), bitCount)
+}
+
+// WriteString : Writes the string with a lengthBitCount length prefix. Fails if it is longer than maxLength octets
+func WriteString(out outbitstream.OutBitStream, s string, lengthBitCount uint, maxLength uint) error {
+	if uint(len(s)) > maxLength {
+		return &outbitstream.OverflowError{Value: uint64(len(s)), BitCount: lengthBitCount, Max: uint64(maxLength), Tell: out.Tell()}
+	}
+	return out.WriteString(s, lengthBitCount)
+}
+
+// WriteLength : Writes a slice length using lengthBitCount bits. Fails if it is longer than maxLength
+func WriteLength(out outbitstream.OutBitStream, length int, lengthBitCount uint, maxLength uint) error {
+	if uint(length) > maxLength {
+		return &outbitstream.OverflowError{Value: uint64(length), BitCount: lengthBitCount, Max: uint64(maxLength), Tell: out.Tell()}
+	}
+	return out.WriteBits(uint32(length), lengthBitCount)
+}
+
+// ReadLength : Reads a length written by WriteLength
+func ReadLength(in inbitstream.InBitStream, lengthBitCount uint, maxLength uint) (int, error) {
+	length, err := in.ReadBits(lengthBitCount)
+	if err != nil {
+		return 0, err
+	}
+	if uint(length) > maxLength {
+		return 0, &inbitstream.OverflowError{Value: uint64(length), Max: uint64(maxLength), Tell: inbitstream.TellOf(in)}
+	}
+	return int(length), nil
+}
+
+// Quantize : Maps v, clamped to min..max, to an integer using bitCount bits
+func Quantize(v float64, min float64, max float64, bitCount uint) uint32 {
+	steps := float64(uint64(1)<<bitCount - 1)
+	normalized := (v - min) / (max - min)
+	if normalized < 0 || math.IsNaN(normalized) {
+		normalized = 0
+	} else if normalized > 1 {
+		normalized = 1
+	}
+	return uint32(math.Round(normalized * steps))
+}
+
+// Dequantize : Maps a quantized integer back to min..max
+func Dequantize(q uint32, min float64, max float64, bitCount uint) float64 {
+	steps := float64(uint64(1)<<bitCount - 1)
+	return min + float64(q)/steps*(max-min)
+}
+
+// WriteQuantized : Writes v quantized to bitCount bits in min..max
+func WriteQuantized(out outbitstream.OutBitStream, v float64, min float64, max float64, bitCount uint) error {
+	return out.WriteBits(Quantize(v, min, max, bitCount), bitCount)
+}
+
+// ReadQuantized : Reads a value written by WriteQuantized
+func ReadQuantized(in inbitstream.InBitStream, min float64, max float64, bitCount uint) (float64, error) {
+	q, err := in.ReadBits(bitCount)
+	if err != nil {
+		return 0, err
+	}
+	return Dequantize(q, min, max, bitCount), nil
+}
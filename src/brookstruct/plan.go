@@ -0,0 +1,221 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package brookstruct ...
+package brookstruct
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// ScalarKind : Go types that map directly to one value in the stream
+type ScalarKind uint8
+
+const (
+	KindBool ScalarKind = iota
+	KindUint8
+	KindUint16
+	KindUint32
+	KindUint64
+	KindUint
+	KindInt8
+	KindInt16
+	KindInt32
+	KindInt64
+	KindInt
+	KindFloat32
+	KindFloat64
+	KindString
+)
+
+// Method : How a scalar is written to and read from a bit stream
+type Method uint8
+
+const (
+	// MethodBool : WriteBool / ReadBool
+	MethodBool Method = iota
+	// MethodBits : WriteBits / ReadBits with Plan.Bits
+	MethodBits
+	// MethodSignedBits : WriteSignedBits / ReadSignedBits with Plan.Bits. Sign and magnitude, so the most
+	// negative value of the type can not be written, e.g. -128 for int8
+	MethodSignedBits
+	// MethodRange : WriteRange / ReadRange with Plan.Min and Plan.Max
+	MethodRange
+	// MethodQuant : WriteQuantized / ReadQuantized with Plan.QuantMin, Plan.QuantMax and Plan.Bits
+	MethodQuant
+	// MethodUint8 : WriteUint8 / ReadUint8
+	MethodUint8
+	// MethodUint16 : WriteUint16 / ReadUint16
+	MethodUint16
+	// MethodUint32 : WriteUint32 / ReadUint32
+	MethodUint32
+	// MethodUint64 : WriteUint64 / ReadUint64, int64 and int are written as their two's complement
+	MethodUint64
+	// MethodInt16 : WriteInt16 / ReadInt16, sign and magnitude like MethodSignedBits
+	MethodInt16
+	// MethodFloat32 : WriteUint32 / ReadUint32 of the IEEE 754 bits
+	MethodFloat32
+	// MethodFloat64 : WriteUint64 / ReadUint64 of the IEEE 754 bits
+	MethodFloat64
+	// MethodString : WriteString / ReadString with Plan.Bits length prefix and Plan.MaxLength
+	MethodString
+)
+
+const (
+	defaultLengthBitCount = 16
+	defaultMaxLength      = 0xffff
+)
+
+// Plan : The resolved encoding of a scalar. Shared by the reflection codecs and the code generators so they write the same bits
+type Plan struct {
+	Method    Method
+	Bits      uint
+	Min       int64
+	Max       int64
+	QuantMin  float64
+	QuantMax  float64
+	MaxLength uint
+}
+
+// LengthPrefix : Bit count of the length prefix and max length for strings and slices
+func LengthPrefix(options Options) (uint, uint) {
+	if options.Max == 0 {
+		return defaultLengthBitCount, defaultMaxLength
+	}
+	return uint(bits.Len(options.Max)), options.Max
+}
+
+// RangeBitCount : Number of bits needed for a value in min..max
+func RangeBitCount(min int64, max int64) uint {
+	return uint(bits.Len64(uint64(max - min)))
+}
+
+func (k ScalarKind) isSigned() bool {
+	return k >= KindInt8 && k <= KindInt
+}
+
+func (k ScalarKind) isInteger() bool {
+	return k >= KindUint8 && k <= KindInt
+}
+
+// bitCount : Number of bits in the Go type of an integer kind
+func (k ScalarKind) bitCount() uint {
+	switch k {
+	case KindUint8, KindInt8:
+		return 8
+	case KindUint16, KindInt16:
+		return 16
+	case KindUint32, KindInt32:
+		return 32
+	}
+	return 64
+}
+
+// fitsRange : Checks that every value in min..max can be stored in an integer kind
+func (k ScalarKind) fitsRange(min int64, max int64) bool {
+	bitCount := k.bitCount()
+	if k.isSigned() {
+		return bitCount == 64 || (min >= -(1<<(bitCount-1)) && max < 1<<(bitCount-1))
+	}
+	return min >= 0 && (bitCount == 64 || max < 1<<bitCount)
+}
+
+// PlanScalar : Resolves how a scalar of the kind is encoded using the tag options
+func PlanScalar(kind ScalarKind, options Options) (Plan, error) {
+	if options.HasRange {
+		if !kind.isInteger() {
+			return Plan{}, fmt.Errorf("brookstruct: range is only supported for integers")
+		}
+		if !kind.fitsRange(options.RangeMin, options.RangeMax) {
+			return Plan{}, fmt.Errorf("brookstruct: range %v..%v does not fit in the %v-bit field", options.RangeMin, options.RangeMax, kind.bitCount())
+		}
+		bitCount := RangeBitCount(options.RangeMin, options.RangeMax)
+		if bitCount > 32 {
+			return Plan{}, fmt.Errorf("brookstruct: range %v..%v needs more than 32 bits", options.RangeMin, options.RangeMax)
+		}
+		return Plan{Method: MethodRange, Bits: bitCount, Min: options.RangeMin, Max: options.RangeMax}, nil
+	}
+
+	if options.HasQuant {
+		if kind != KindFloat32 && kind != KindFloat64 {
+			return Plan{}, fmt.Errorf("brookstruct: quant is only supported for floats")
+		}
+		if options.Bits == 0 || options.Bits > 32 {
+			return Plan{}, fmt.Errorf("brookstruct: quant needs bits=1..32")
+		}
+		return Plan{Method: MethodQuant, Bits: options.Bits, QuantMin: options.QuantMin, QuantMax: options.QuantMax}, nil
+	}
+
+	if options.Bits != 0 {
+		if !kind.isInteger() {
+			if kind == KindString {
+				return Plan{}, fmt.Errorf("brookstruct: use max to limit string length")
+			}
+			return Plan{}, fmt.Errorf("brookstruct: bits is only supported for integers and quantized floats")
+		}
+		if options.Bits > 32 {
+			return Plan{}, fmt.Errorf("brookstruct: max 32 bits, use the default encoding for 64-bit values")
+		}
+		if options.Bits > kind.bitCount() {
+			return Plan{}, fmt.Errorf("brookstruct: bits=%v is wider than the %v-bit field", options.Bits, kind.bitCount())
+		}
+		if kind.isSigned() {
+			if options.Bits < 2 {
+				return Plan{}, fmt.Errorf("brookstruct: signed values need at least 2 bits")
+			}
+			return Plan{Method: MethodSignedBits, Bits: options.Bits}, nil
+		}
+		return Plan{Method: MethodBits, Bits: options.Bits}, nil
+	}
+
+	switch kind {
+	case KindBool:
+		return Plan{Method: MethodBool, Bits: 1}, nil
+	case KindUint8:
+		return Plan{Method: MethodUint8, Bits: 8}, nil
+	case KindUint16:
+		return Plan{Method: MethodUint16, Bits: 16}, nil
+	case KindUint32:
+		return Plan{Method: MethodUint32, Bits: 32}, nil
+	case KindUint64, KindUint, KindInt64, KindInt:
+		return Plan{Method: MethodUint64, Bits: 64}, nil
+	case KindInt8:
+		return Plan{Method: MethodSignedBits, Bits: 8}, nil
+	case KindInt16:
+		return Plan{Method: MethodInt16, Bits: 16}, nil
+	case KindInt32:
+		return Plan{Method: MethodSignedBits, Bits: 32}, nil
+	case KindFloat32:
+		return Plan{Method: MethodFloat32, Bits: 32}, nil
+	case KindFloat64:
+		return Plan{Method: MethodFloat64, Bits: 64}, nil
+	case KindString:
+		lengthBitCount, maxLength := LengthPrefix(options)
+		return Plan{Method: MethodString, Bits: lengthBitCount, MaxLength: maxLength}, nil
+	}
+	return Plan{}, fmt.Errorf("brookstruct: unsupported kind %v", kind)
+}
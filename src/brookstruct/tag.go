@@ -0,0 +1,117 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package brookstruct ...
+package brookstruct
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Options : Parsed `brook:"..."` struct tag
+type Options struct {
+	Skip bool
+
+	// Bits : Bit count for integers and quantized floats, zero for the default
+	Bits uint
+
+	HasRange bool
+	RangeMin int64
+	RangeMax int64
+
+	HasQuant bool
+	QuantMin float64
+	QuantMax float64
+
+	// Max : Max length of strings and slices, zero for the default
+	Max uint
+}
+
+func parseRange(value string) (string, string, error) {
+	parts := strings.SplitN(value, "..", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("expected min..max but got %q", value)
+	}
+	return parts[0], parts[1], nil
+}
+
+// ParseTag : Parses a tag such as "bits=5", "range=-100..100", "quant=0..1,bits=10", "max=32" or "skip"
+func ParseTag(tag string) (Options, error) {
+	var options Options
+	if tag == "" {
+		return options, nil
+	}
+	for _, part := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "skip":
+			options.Skip = true
+		case "bits":
+			bitCount, err := strconv.ParseUint(value, 10, 8)
+			if err != nil || bitCount == 0 {
+				return options, fmt.Errorf("brookstruct: illegal bits %q", value)
+			}
+			options.Bits = uint(bitCount)
+		case "max":
+			max, err := strconv.ParseUint(value, 10, 32)
+			if err != nil || max == 0 {
+				return options, fmt.Errorf("brookstruct: illegal max %q", value)
+			}
+			options.Max = uint(max)
+		case "range":
+			minString, maxString, rangeErr := parseRange(value)
+			if rangeErr != nil {
+				return options, fmt.Errorf("brookstruct: range: %w", rangeErr)
+			}
+			min, minErr := strconv.ParseInt(minString, 10, 64)
+			max, maxErr := strconv.ParseInt(maxString, 10, 64)
+			if minErr != nil || maxErr != nil || min >= max {
+				return options, fmt.Errorf("brookstruct: illegal range %q", value)
+			}
+			options.HasRange = true
+			options.RangeMin = min
+			options.RangeMax = max
+		case "quant":
+			minString, maxString, rangeErr := parseRange(value)
+			if rangeErr != nil {
+				return options, fmt.Errorf("brookstruct: quant: %w", rangeErr)
+			}
+			min, minErr := strconv.ParseFloat(minString, 64)
+			max, maxErr := strconv.ParseFloat(maxString, 64)
+			if minErr != nil || maxErr != nil || min >= max {
+				return options, fmt.Errorf("brookstruct: illegal quant %q", value)
+			}
+			options.HasQuant = true
+			options.QuantMin = min
+			options.QuantMax = max
+		default:
+			return options, fmt.Errorf("brookstruct: unknown tag option %q", key)
+		}
+	}
+	return options, nil
+}
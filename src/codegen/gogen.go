@@ -0,0 +1,295 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package codegen ...
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+
+	"github.com/piot/brook-go/src/brookstruct"
+)
+
+type goWriter struct {
+	buffer        bytes.Buffer
+	indent        int
+	usesMath      bool
+	usesBrookType bool
+}
+
+func (w *goWriter) line(format string, args ...interface{}) {
+	w.buffer.WriteString(strings.Repeat("\t", w.indent))
+	fmt.Fprintf(&w.buffer, format, args...)
+	w.buffer.WriteString("\n")
+}
+
+func (w *goWriter) check(call string) {
+	w.line("if err := %v; err != nil {", call)
+	w.line("\treturn err")
+	w.line("}")
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func (w *goWriter) writeScalarCall(t *Type, expr string) string {
+	plan := t.Plan
+	switch plan.Method {
+	case brookstruct.MethodBool:
+		return fmt.Sprintf("outbitstream.WriteBool(out, bool(%v))", expr)
+	case brookstruct.MethodBits:
+		w.usesBrookType = true
+		return fmt.Sprintf("brookstruct.WriteUnsignedBits(out, uint64(%v), %d)", expr, plan.Bits)
+	case brookstruct.MethodSignedBits:
+		w.usesBrookType = true
+		return fmt.Sprintf("brookstruct.WriteSignedBits(out, int64(%v), %d)", expr, plan.Bits)
+	case brookstruct.MethodRange:
+		w.usesBrookType = true
+		return fmt.Sprintf("brookstruct.WriteRange(out, int64(%v), %d, %d)", expr, plan.Min, plan.Max)
+	case brookstruct.MethodQuant:
+		w.usesBrookType = true
+		return fmt.Sprintf("brookstruct.WriteQuantized(out, float64(%v), %v, %v, %d)", expr, formatFloat(plan.QuantMin), formatFloat(plan.QuantMax), plan.Bits)
+	case brookstruct.MethodUint8:
+		return fmt.Sprintf("out.WriteUint8(uint8(%v))", expr)
+	case brookstruct.MethodUint16:
+		return fmt.Sprintf("out.WriteUint16(uint16(%v))", expr)
+	case brookstruct.MethodUint32:
+		return fmt.Sprintf("out.WriteUint32(uint32(%v))", expr)
+	case brookstruct.MethodUint64:
+		return fmt.Sprintf("out.WriteUint64(uint64(%v))", expr)
+	case brookstruct.MethodInt16:
+		w.usesBrookType = true
+		return fmt.Sprintf("brookstruct.WriteSignedBits(out, int64(%v), 16)", expr)
+	case brookstruct.MethodFloat32:
+		w.usesMath = true
+		return fmt.Sprintf("out.WriteUint32(math.Float32bits(float32(%v)))", expr)
+	case brookstruct.MethodFloat64:
+		w.usesMath = true
+		return fmt.Sprintf("out.WriteUint64(math.Float64bits(float64(%v)))", expr)
+	case brookstruct.MethodString:
+		w.usesBrookType = true
+		return fmt.Sprintf("brookstruct.WriteString(out, string(%v), %d, %d)", expr, plan.Bits, plan.MaxLength)
+	}
+	panic(fmt.Sprintf("codegen: unknown method %v", plan.Method))
+}
+
+// readScalarCall returns the read call and how to convert its result to the field type
+func (w *goWriter) readScalarCall(t *Type) (string, string) {
+	plan := t.Plan
+	converted := t.GoName + "(x)"
+	switch plan.Method {
+	case brookstruct.MethodBool:
+		return "inbitstream.ReadBool(in)", converted
+	case brookstruct.MethodBits:
+		return fmt.Sprintf("in.ReadBits(%d)", plan.Bits), converted
+	case brookstruct.MethodSignedBits:
+		return fmt.Sprintf("in.ReadSignedBits(%d)", plan.Bits), converted
+	case brookstruct.MethodRange:
+		w.usesBrookType = true
+		return fmt.Sprintf("brookstruct.ReadRange(in, %d, %d)", plan.Min, plan.Max), converted
+	case brookstruct.MethodQuant:
+		w.usesBrookType = true
+		return fmt.Sprintf("brookstruct.ReadQuantized(in, %v, %v, %d)", formatFloat(plan.QuantMin), formatFloat(plan.QuantMax), plan.Bits), converted
+	case brookstruct.MethodUint8:
+		return "in.ReadUint8()", converted
+	case brookstruct.MethodUint16:
+		return "in.ReadUint16()", converted
+	case brookstruct.MethodUint32:
+		return "in.ReadUint32()", converted
+	case brookstruct.MethodUint64:
+		return "in.ReadUint64()", converted
+	case brookstruct.MethodInt16:
+		return "in.ReadInt16()", converted
+	case brookstruct.MethodFloat32:
+		w.usesMath = true
+		return "in.ReadUint32()", t.GoName + "(math.Float32frombits(x))"
+	case brookstruct.MethodFloat64:
+		w.usesMath = true
+		return "in.ReadUint64()", t.GoName + "(math.Float64frombits(x))"
+	case brookstruct.MethodString:
+		return fmt.Sprintf("in.ReadString(%d, %d)", plan.Bits, plan.MaxLength), converted
+	}
+	panic(fmt.Sprintf("codegen: unknown method %v", plan.Method))
+}
+
+func (w *goWriter) writeValue(t *Type, expr string, depth int) {
+	switch t.Kind {
+	case TypeScalar:
+		w.check(w.writeScalarCall(t, expr))
+	case TypeStruct:
+		w.check(expr + ".MarshalBrook(out)")
+	case TypePointer:
+		w.check(fmt.Sprintf("outbitstream.WriteBool(out, %v != nil)", expr))
+		w.line("if %v != nil {", expr)
+		w.indent++
+		w.writeValue(t.Elem, "(*"+expr+")", depth)
+		w.indent--
+		w.line("}")
+	case TypeSlice, TypeArray:
+		if t.Kind == TypeSlice {
+			w.usesBrookType = true
+			w.check(fmt.Sprintf("brookstruct.WriteLength(out, len(%v), %d, %d)", expr, t.LengthBitCount, t.MaxLength))
+		}
+		index := fmt.Sprintf("i%d", depth)
+		w.line("for %v := range %v {", index, expr)
+		w.indent++
+		w.writeValue(t.Elem, fmt.Sprintf("%v[%v]", expr, index), depth+1)
+		w.indent--
+		w.line("}")
+	}
+}
+
+func (w *goWriter) readValue(t *Type, expr string, depth int) {
+	switch t.Kind {
+	case TypeScalar:
+		call, converted := w.readScalarCall(t)
+		w.line("{")
+		w.indent++
+		w.line("x, err := %v", call)
+		w.line("if err != nil {")
+		w.line("\treturn err")
+		w.line("}")
+		w.line("%v = %v", expr, converted)
+		w.indent--
+		w.line("}")
+	case TypeStruct:
+		w.check(expr + ".UnmarshalBrook(in)")
+	case TypePointer:
+		w.line("{")
+		w.indent++
+		w.line("present, err := inbitstream.ReadBool(in)")
+		w.line("if err != nil {")
+		w.line("\treturn err")
+		w.line("}")
+		w.line("%v = nil", expr)
+		w.line("if present {")
+		w.indent++
+		w.line("%v = new(%v)", expr, t.Elem.GoName)
+		w.readValue(t.Elem, "(*"+expr+")", depth)
+		w.indent--
+		w.line("}")
+		w.indent--
+		w.line("}")
+	case TypeSlice:
+		w.usesBrookType = true
+		w.line("{")
+		w.indent++
+		w.line("count, err := brookstruct.ReadLength(in, %d, %d)", t.LengthBitCount, t.MaxLength)
+		w.line("if err != nil {")
+		w.line("\treturn err")
+		w.line("}")
+		w.line("%v = make(%v, count)", expr, t.GoName)
+		w.indent--
+		w.line("}")
+		w.readElements(t, expr, depth)
+	case TypeArray:
+		w.readElements(t, expr, depth)
+	}
+}
+
+func (w *goWriter) readElements(t *Type, expr string, depth int) {
+	index := fmt.Sprintf("i%d", depth)
+	w.line("for %v := range %v {", index, expr)
+	w.indent++
+	w.readValue(t.Elem, fmt.Sprintf("%v[%v]", expr, index), depth+1)
+	w.indent--
+	w.line("}")
+}
+
+func (w *goWriter) writeMethods(s Struct) {
+	w.line("// MarshalBrook : Writes %v to the bit stream", s.Name)
+	w.line("func (v *%v) MarshalBrook(out outbitstream.OutBitStream) error {", s.Name)
+	w.indent++
+	for _, field := range s.Fields {
+		w.writeValue(field.Type, "v."+field.Name, 0)
+	}
+	w.line("return nil")
+	w.indent--
+	w.line("}")
+	w.line("")
+	w.line("// UnmarshalBrook : Reads %v from the bit stream", s.Name)
+	w.line("func (v *%v) UnmarshalBrook(in inbitstream.InBitStream) error {", s.Name)
+	w.indent++
+	for _, field := range s.Fields {
+		w.readValue(field.Type, "v."+field.Name, 0)
+	}
+	w.line("return nil")
+	w.indent--
+	w.line("}")
+	w.line("")
+}
+
+// GoFile : Everything needed to emit one generated Go file
+type GoFile struct {
+	Package string
+	// Generator : Name of the generating command, used in the DO NOT EDIT header
+	Generator string
+	// Declarations : Extra Go source placed before the methods, e.g. type declarations
+	Declarations string
+	Structs      []Struct
+}
+
+// GenerateGo : Emits MarshalBrook and UnmarshalBrook methods for the structs
+func GenerateGo(file GoFile) ([]byte, error) {
+	var body goWriter
+	for _, s := range file.Structs {
+		body.writeMethods(s)
+	}
+
+	var w goWriter
+	w.line("// Code generated by %v. DO NOT EDIT.", file.Generator)
+	w.line("")
+	w.line("package %v", file.Package)
+	w.line("")
+	w.line("import (")
+	if body.usesMath {
+		w.line("\t\"math\"")
+		w.line("")
+	}
+	if body.usesBrookType {
+		w.line("\t\"github.com/piot/brook-go/src/brookstruct\"")
+	}
+	w.line("\t\"github.com/piot/brook-go/src/inbitstream\"")
+	w.line("\t\"github.com/piot/brook-go/src/outbitstream\"")
+	w.line(")")
+	w.line("")
+	if file.Declarations != "" {
+		w.buffer.WriteString(file.Declarations)
+		w.line("")
+	}
+	w.buffer.Write(body.buffer.Bytes())
+
+	formatted, formatErr := format.Source(w.buffer.Bytes())
+	if formatErr != nil {
+		return w.buffer.Bytes(), fmt.Errorf("codegen: generated code does not compile: %w", formatErr)
+	}
+	return formatted, nil
+}
@@ -0,0 +1,141 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package codegen emits Go serialization code that writes the same bits as brookstruct
+package codegen
+
+import (
+	"fmt"
+
+	"github.com/piot/brook-go/src/brookstruct"
+)
+
+// TypeKind : Kind of a type in the model
+type TypeKind uint8
+
+const (
+	TypeScalar TypeKind = iota
+	TypeStruct
+	TypeSlice
+	TypeArray
+	TypePointer
+)
+
+// Type : A field type resolved to its encoding
+type Type struct {
+	Kind TypeKind
+
+	// GoName : Go type expression, e.g. "uint8", "Weapon", "[]Item" or "*Vector"
+	GoName string
+
+	// Scalar and Plan are set for scalars
+	Scalar brookstruct.ScalarKind
+	Plan   brookstruct.Plan
+
+	// Elem is set for slices, arrays and pointers
+	Elem *Type
+
+	ArrayLength    int
+	LengthBitCount uint
+	MaxLength      uint
+}
+
+// Field : A serialized struct field
+type Field struct {
+	Name string
+	Type *Type
+}
+
+// Struct : A struct that gets MarshalBrook and UnmarshalBrook methods
+type Struct struct {
+	Name   string
+	Fields []Field
+}
+
+// ScalarKindFromName : Finds the scalar kind of a predeclared Go type name
+func ScalarKindFromName(name string) (brookstruct.ScalarKind, bool) {
+	switch name {
+	case "bool":
+		return brookstruct.KindBool, true
+	case "uint8", "byte":
+		return brookstruct.KindUint8, true
+	case "uint16":
+		return brookstruct.KindUint16, true
+	case "uint32":
+		return brookstruct.KindUint32, true
+	case "uint64":
+		return brookstruct.KindUint64, true
+	case "uint":
+		return brookstruct.KindUint, true
+	case "int8":
+		return brookstruct.KindInt8, true
+	case "int16":
+		return brookstruct.KindInt16, true
+	case "int32", "rune":
+		return brookstruct.KindInt32, true
+	case "int64":
+		return brookstruct.KindInt64, true
+	case "int":
+		return brookstruct.KindInt, true
+	case "float32":
+		return brookstruct.KindFloat32, true
+	case "float64":
+		return brookstruct.KindFloat64, true
+	case "string":
+		return brookstruct.KindString, true
+	}
+	return 0, false
+}
+
+// NewScalar : Scalar type encoded according to the options
+func NewScalar(goName string, kind brookstruct.ScalarKind, options brookstruct.Options) (*Type, error) {
+	plan, planErr := brookstruct.PlanScalar(kind, options)
+	if planErr != nil {
+		return nil, planErr
+	}
+	return &Type{Kind: TypeScalar, GoName: goName, Scalar: kind, Plan: plan}, nil
+}
+
+// NewStructRef : Reference to a struct that also has generated methods
+func NewStructRef(name string) *Type {
+	return &Type{Kind: TypeStruct, GoName: name}
+}
+
+// NewSlice : Length prefixed slice, using the max option for the length prefix like brookstruct
+func NewSlice(elem *Type, options brookstruct.Options) *Type {
+	lengthBitCount, maxLength := brookstruct.LengthPrefix(options)
+	return &Type{Kind: TypeSlice, GoName: "[]" + elem.GoName, Elem: elem, LengthBitCount: lengthBitCount, MaxLength: maxLength}
+}
+
+// NewArray : Fixed length array
+func NewArray(elem *Type, length int) *Type {
+	return &Type{Kind: TypeArray, GoName: fmt.Sprintf("[%d]%v", length, elem.GoName), Elem: elem, ArrayLength: length}
+}
+
+// NewPointer : Optional value with a presence bit
+func NewPointer(elem *Type) *Type {
+	return &Type{Kind: TypePointer, GoName: "*" + elem.GoName, Elem: elem}
+}
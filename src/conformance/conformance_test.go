@@ -0,0 +1,89 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package conformance
+
+import (
+	"testing"
+)
+
+func loadVectors(t *testing.T) []*Vector {
+	vectors, err := LoadDir("../../conformance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vectors) == 0 {
+		t.Fatal("Expected vectors in the conformance directory")
+	}
+	return vectors
+}
+
+func TestWrite(t *testing.T) {
+	for _, v := range loadVectors(t) {
+		err := CheckWrite(v)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestRead(t *testing.T) {
+	for _, v := range loadVectors(t) {
+		err := CheckRead(v)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestReadDetectsWrongOctets(t *testing.T) {
+	v, err := Parse("wrong", "uint16 0xbeef\nexpect-bits 16\nexpect-octets BEEE\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if CheckRead(v) == nil || CheckWrite(v) == nil {
+		t.Errorf("Expected mismatch to be reported")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	sources := []string{
+		"bits 1\nexpect-bits 1\nexpect-octets 80\n",
+		"bits 1 33\nexpect-bits 1\nexpect-octets 80\n",
+		"jump 1\nexpect-bits 1\nexpect-octets 80\n",
+		"format debug-v9\nexpect-bits 0\nexpect-octets -\n",
+		"uint8 1\n",
+		"uint8 1\nexpect-bits 8\nexpect-octets 0100\n",
+		"expect-bits 8\nexpect-octets 01\nuint8 1\n",
+		"uint8 256\nexpect-bits 8\nexpect-octets 00\n",
+	}
+	for _, source := range sources {
+		_, err := Parse("test", source)
+		if err == nil {
+			t.Errorf("Expected error for %q", source)
+		}
+	}
+}
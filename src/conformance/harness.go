@@ -0,0 +1,242 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package conformance ...
+package conformance
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/piot/brook-go/src/debugtag"
+	"github.com/piot/brook-go/src/inbitstream"
+	"github.com/piot/brook-go/src/outbitstream"
+)
+
+type labeler interface {
+	Label(name string)
+}
+
+// octetCapacity returns enough octets for every operation, including debug tags and the
+// four spare octets the implementation needs after the last written octet
+func octetCapacity(v *Vector) int {
+	capacity := 8
+	for _, op := range v.Ops {
+		capacity += 16 + len(op.Octets)
+	}
+	return capacity
+}
+
+func newOutStream(v *Vector) (outbitstream.OutBitStream, *outbitstream.OutBitStreamImpl, error) {
+	impl := outbitstream.New(octetCapacity(v))
+	switch v.Format {
+	case FormatDebugV1:
+		return outbitstream.NewDebugStream(impl), impl, nil
+	case FormatDebugV2, FormatDebugV2Labeled:
+		stream, err := outbitstream.NewDebugStreamWithFormat(impl, debugtag.FormatVersion2, v.Format == FormatDebugV2Labeled)
+		return stream, impl, err
+	}
+	return impl, impl, nil
+}
+
+func newInStream(v *Vector) (inbitstream.InBitStream, *inbitstream.InBitStreamImpl, error) {
+	impl := inbitstream.New(v.Octets, v.BitCount)
+	switch v.Format {
+	case FormatDebugV1:
+		return inbitstream.NewDebugStream(impl), impl, nil
+	case FormatDebugV2, FormatDebugV2Labeled:
+		stream, err := inbitstream.NewDebugStreamFromHeader(impl)
+		return stream, impl, err
+	}
+	return impl, impl, nil
+}
+
+func writeOp(out outbitstream.OutBitStream, op Op) error {
+	switch op.Name {
+	case "bits":
+		return out.WriteBits(uint32(op.Value), op.Count)
+	case "signed":
+		return out.WriteSignedBits(int32(op.Signed), op.Count)
+	case "uint8":
+		return out.WriteUint8(uint8(op.Value))
+	case "uint16":
+		return out.WriteUint16(uint16(op.Value))
+	case "uint32":
+		return out.WriteUint32(uint32(op.Value))
+	case "uint64":
+		return out.WriteUint64(op.Value)
+	case "int16":
+		return out.WriteInt16(int16(op.Signed))
+	case "int32":
+		return out.WriteInt32(int32(op.Signed))
+	case "string":
+		return out.WriteString(string(op.Octets), op.Count)
+	case "blob":
+		return out.WriteBlob(op.Octets, op.Count)
+	case "label":
+		l, isLabeler := out.(labeler)
+		if !isLabeler {
+			return fmt.Errorf("label needs a debug stream")
+		}
+		l.Label(op.Label)
+		return nil
+	}
+	return fmt.Errorf("unknown operation %v", op.Name)
+}
+
+// readOp reads the value written by op and compares it
+func readOp(in inbitstream.InBitStream, op Op) error {
+	var (
+		unsigned uint64
+		signed   int64
+		octets   []byte
+		err      error
+	)
+	switch op.Name {
+	case "bits":
+		var v uint32
+		v, err = in.ReadBits(op.Count)
+		unsigned = uint64(v)
+	case "signed", "int32":
+		count := op.Count
+		if op.Name == "int32" {
+			count = 32
+		}
+		var v int32
+		v, err = in.ReadSignedBits(count)
+		signed = int64(v)
+	case "uint8":
+		var v uint8
+		v, err = in.ReadUint8()
+		unsigned = uint64(v)
+	case "uint16":
+		var v uint16
+		v, err = in.ReadUint16()
+		unsigned = uint64(v)
+	case "uint32":
+		var v uint32
+		v, err = in.ReadUint32()
+		unsigned = uint64(v)
+	case "uint64":
+		unsigned, err = in.ReadUint64()
+	case "int16":
+		var v int16
+		v, err = in.ReadInt16()
+		signed = int64(v)
+	case "string":
+		var v string
+		v, err = in.ReadString(op.Count, uint(len(op.Octets)))
+		octets = []byte(v)
+	case "blob":
+		octets, err = in.ReadBlob(op.Count, uint(len(op.Octets)))
+	case "label":
+		l, isLabeler := in.(labeler)
+		if !isLabeler {
+			return fmt.Errorf("label needs a debug stream")
+		}
+		l.Label(op.Label)
+		return nil
+	default:
+		return fmt.Errorf("unknown operation %v", op.Name)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch op.Name {
+	case "signed", "int16", "int32":
+		if signed != op.Signed {
+			return fmt.Errorf("expected %d but read %d", op.Signed, signed)
+		}
+	case "string", "blob":
+		if !bytes.Equal(octets, op.Octets) {
+			return fmt.Errorf("expected %X but read %X", op.Octets, octets)
+		}
+	default:
+		if unsigned != op.Value {
+			return fmt.Errorf("expected 0x%X but read 0x%X", op.Value, unsigned)
+		}
+	}
+	return nil
+}
+
+// Write : Performs the operations and returns the written octets and bit count
+func Write(v *Vector) ([]byte, uint, error) {
+	out, impl, createErr := newOutStream(v)
+	if createErr != nil {
+		return nil, 0, createErr
+	}
+	for _, op := range v.Ops {
+		writeErr := writeOp(out, op)
+		if writeErr != nil {
+			return nil, 0, fmt.Errorf("%v:%d: %v: %w", v.Name, op.Line, op.Name, writeErr)
+		}
+	}
+	out.Close()
+	octets := impl.Octets()
+	return append([]byte{}, octets...), out.Tell(), nil
+}
+
+// CheckWrite : Checks that OutBitStreamImpl writes exactly the expected octets and bit count
+func CheckWrite(v *Vector) error {
+	octets, bitCount, writeErr := Write(v)
+	if writeErr != nil {
+		return writeErr
+	}
+	if bitCount != v.BitCount {
+		return fmt.Errorf("%v: expected %d bits but wrote %d", v.Name, v.BitCount, bitCount)
+	}
+	if !bytes.Equal(octets, v.Octets) {
+		return fmt.Errorf("%v: expected %X but wrote %X", v.Name, v.Octets, octets)
+	}
+	return nil
+}
+
+// CheckRead : Checks that InBitStreamImpl reads back every value from the expected octets,
+// ending exactly at the expected bit count
+func CheckRead(v *Vector) error {
+	in, impl, createErr := newInStream(v)
+	if createErr != nil {
+		return fmt.Errorf("%v: %w", v.Name, createErr)
+	}
+	for _, op := range v.Ops {
+		readErr := readOp(in, op)
+		if readErr != nil {
+			return fmt.Errorf("%v:%d: %v: %w", v.Name, op.Line, op.Name, readErr)
+		}
+	}
+	if impl.Tell() != v.BitCount {
+		return fmt.Errorf("%v: expected to read %d bits but read %d", v.Name, v.BitCount, impl.Tell())
+	}
+	if !impl.IsEOF() {
+		return fmt.Errorf("%v: expected end of stream after %d bits", v.Name, v.BitCount)
+	}
+	_, overrunErr := impl.ReadBits(1)
+	if overrunErr == nil {
+		return fmt.Errorf("%v: expected reading past the end to fail", v.Name)
+	}
+	return nil
+}
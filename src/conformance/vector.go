@@ -0,0 +1,245 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package conformance runs the golden vectors in the conformance directory against the bit streams.
+// The vector format is described in conformance/readme.md and is shared with Brook-Dotnet.
+package conformance
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Format : Stream format a vector is written with
+type Format string
+
+const (
+	FormatPlain          Format = "plain"
+	FormatDebugV1        Format = "debug-v1"
+	FormatDebugV2        Format = "debug-v2"
+	FormatDebugV2Labeled Format = "debug-v2-labeled"
+)
+
+// Op : One write, and the matching read, in a vector
+type Op struct {
+	Line int
+	Name string
+
+	// Value : Unsigned value for bits and the unsigned fixed size operations
+	Value uint64
+	// Signed : Value for signed, int16 and int32
+	Signed int64
+	// Count : Bit count for bits and signed, length prefix bit count for string and blob
+	Count uint
+	// Octets : Payload for string and blob
+	Octets []byte
+	// Label : Name for label
+	Label string
+}
+
+// Vector : A sequence of operations and the octets they must produce
+type Vector struct {
+	Name     string
+	Format   Format
+	Ops      []Op
+	BitCount uint
+	Octets   []byte
+}
+
+func parseUnsigned(text string, bitSize int) (uint64, error) {
+	return strconv.ParseUint(text, 0, bitSize)
+}
+
+func parseSigned(text string, bitSize int) (int64, error) {
+	return strconv.ParseInt(text, 0, bitSize)
+}
+
+func parseHex(text string) ([]byte, error) {
+	if text == "-" {
+		return []byte{}, nil
+	}
+	return hex.DecodeString(text)
+}
+
+func parseOp(fields []string, line int) (Op, error) {
+	op := Op{Line: line, Name: fields[0]}
+	args := fields[1:]
+	expectArgs := func(count int) error {
+		if len(args) != count {
+			return fmt.Errorf("%v expects %d arguments but got %d", op.Name, count, len(args))
+		}
+		return nil
+	}
+
+	var err error
+	switch op.Name {
+	case "bits", "signed":
+		if err = expectArgs(2); err != nil {
+			return op, err
+		}
+		var count uint64
+		count, err = parseUnsigned(args[1], 8)
+		if err == nil && (count == 0 || count > 32) {
+			err = fmt.Errorf("bit count must be 1..32")
+		}
+		op.Count = uint(count)
+		if err == nil && op.Name == "bits" {
+			op.Value, err = parseUnsigned(args[0], 32)
+		} else if err == nil {
+			op.Signed, err = parseSigned(args[0], 32)
+		}
+	case "uint8", "uint16", "uint32", "uint64":
+		if err = expectArgs(1); err != nil {
+			return op, err
+		}
+		bitSize, _ := strconv.Atoi(strings.TrimPrefix(op.Name, "uint"))
+		op.Value, err = parseUnsigned(args[0], bitSize)
+	case "int16", "int32":
+		if err = expectArgs(1); err != nil {
+			return op, err
+		}
+		bitSize, _ := strconv.Atoi(strings.TrimPrefix(op.Name, "int"))
+		op.Signed, err = parseSigned(args[0], bitSize)
+	case "string", "blob":
+		if err = expectArgs(2); err != nil {
+			return op, err
+		}
+		var count uint64
+		count, err = parseUnsigned(args[0], 8)
+		op.Count = uint(count)
+		if err == nil {
+			op.Octets, err = parseHex(args[1])
+		}
+	case "label":
+		if err = expectArgs(1); err != nil {
+			return op, err
+		}
+		op.Label = args[0]
+	default:
+		return op, fmt.Errorf("unknown operation %v", op.Name)
+	}
+	return op, err
+}
+
+// Parse : Parses a vector. The name is only used in error messages
+func Parse(name string, source string) (*Vector, error) {
+	v := &Vector{Name: name, Format: FormatPlain}
+	hasBitCount := false
+	hasOctets := false
+	scanner := bufio.NewScanner(strings.NewReader(source))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		text := scanner.Text()
+		if commentStart := strings.Index(text, "#"); commentStart >= 0 {
+			text = text[:commentStart]
+		}
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+
+		var lineErr error
+		switch fields[0] {
+		case "format":
+			if len(fields) != 2 {
+				lineErr = fmt.Errorf("format expects one argument")
+				break
+			}
+			v.Format = Format(fields[1])
+			switch v.Format {
+			case FormatPlain, FormatDebugV1, FormatDebugV2, FormatDebugV2Labeled:
+			default:
+				lineErr = fmt.Errorf("unknown format %v", fields[1])
+			}
+		case "expect-bits":
+			if len(fields) != 2 {
+				lineErr = fmt.Errorf("expect-bits expects one argument")
+				break
+			}
+			var bitCount uint64
+			bitCount, lineErr = parseUnsigned(fields[1], 32)
+			v.BitCount = uint(bitCount)
+			hasBitCount = true
+		case "expect-octets":
+			for _, field := range fields[1:] {
+				octets, hexErr := parseHex(field)
+				if hexErr != nil {
+					lineErr = hexErr
+					break
+				}
+				v.Octets = append(v.Octets, octets...)
+			}
+			hasOctets = true
+		default:
+			if hasBitCount || hasOctets {
+				lineErr = fmt.Errorf("operations must come before the expectations")
+				break
+			}
+			var op Op
+			op, lineErr = parseOp(fields, lineNumber)
+			v.Ops = append(v.Ops, op)
+		}
+		if lineErr != nil {
+			return nil, fmt.Errorf("%v:%d: %w", name, lineNumber, lineErr)
+		}
+	}
+	if !hasBitCount || !hasOctets {
+		return nil, fmt.Errorf("%v: expect-bits and expect-octets are required", name)
+	}
+	if uint(len(v.Octets)) != (v.BitCount+7)/8 {
+		return nil, fmt.Errorf("%v: expected %d octets for %d bits but got %d", name, (v.BitCount+7)/8, v.BitCount, len(v.Octets))
+	}
+	return v, nil
+}
+
+// LoadDir : Loads all *.vector files in a directory, sorted by name
+func LoadDir(dir string) ([]*Vector, error) {
+	paths, globErr := filepath.Glob(filepath.Join(dir, "*.vector"))
+	if globErr != nil {
+		return nil, globErr
+	}
+	sort.Strings(paths)
+	var vectors []*Vector
+	for _, path := range paths {
+		source, readErr := os.ReadFile(path)
+		if readErr != nil {
+			return nil, readErr
+		}
+		v, parseErr := Parse(filepath.Base(path), string(source))
+		if parseErr != nil {
+			return nil, parseErr
+		}
+		vectors = append(vectors, v)
+	}
+	return vectors, nil
+}
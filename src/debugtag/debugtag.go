@@ -0,0 +1,134 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package debugtag keeps track of the type tags written by debug bit streams
+package debugtag
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Tag : Type tag written in front of each value in a debug stream
+type Tag uint8
+
+const (
+	Uint16   Tag = 1
+	Int16    Tag = 2
+	Uint32   Tag = 3
+	Uint64   Tag = 4
+	Uint8    Tag = 5
+	Signed   Tag = 6
+	Unsigned Tag = 7
+	String   Tag = 8
+	Int32    Tag = 9
+	Blob     Tag = 10
+
+	// FirstUserTag : Tags from here and up are free for user defined types
+	FirstUserTag Tag = 32
+)
+
+// Kind : Describes what follows the tag in the stream
+type Kind uint8
+
+const (
+	// KindUnsigned : Unsigned value with the bit count from the tag
+	KindUnsigned Kind = iota
+	// KindSigned : Sign bit followed by the magnitude, bit count from the tag
+	KindSigned
+	// KindString : Length with the bit count from the tag, followed by UTF-8 octets
+	KindString
+	// KindBlob : Length with the bit count from the tag, followed by octets
+	KindBlob
+	// KindMarker : No value follows, marks the start of a user defined type
+	KindMarker
+)
+
+// Info : Registered information about a tag
+type Info struct {
+	Tag  Tag
+	Name string
+	Kind Kind
+}
+
+var (
+	registryLock sync.RWMutex
+	registry     = map[Tag]Info{}
+)
+
+func init() {
+	builtins := []Info{
+		{Uint16, "uint16", KindUnsigned},
+		{Int16, "int16", KindSigned},
+		{Uint32, "uint32", KindUnsigned},
+		{Uint64, "uint64", KindUnsigned},
+		{Uint8, "uint8", KindUnsigned},
+		{Signed, "signed", KindSigned},
+		{Unsigned, "unsigned", KindUnsigned},
+		{String, "string", KindString},
+		{Int32, "int32", KindSigned},
+		{Blob, "blob", KindBlob},
+	}
+	for _, info := range builtins {
+		registry[info.Tag] = info
+	}
+}
+
+// Register : Registers a tag with a display name. Fails if the tag is already taken or is below
+// FirstUserTag, which is reserved for built-in types
+func Register(tag Tag, name string, kind Kind) error {
+	if tag < FirstUserTag {
+		return fmt.Errorf("debugtag: tag %d is reserved for built-in types, use %d and up", uint8(tag), uint8(FirstUserTag))
+	}
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	if existing, exists := registry[tag]; exists {
+		return fmt.Errorf("debugtag: tag %d is already registered as %v", uint8(tag), existing.Name)
+	}
+	registry[tag] = Info{Tag: tag, Name: name, Kind: kind}
+	return nil
+}
+
+// Lookup : Finds the registered information for a tag
+func Lookup(tag Tag) (Info, bool) {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	info, exists := registry[tag]
+	return info, exists
+}
+
+// Name : Display name of the tag, or "unknown"
+func Name(tag Tag) string {
+	info, exists := Lookup(tag)
+	if !exists {
+		return "unknown"
+	}
+	return info.Name
+}
+
+func (t Tag) String() string {
+	return fmt.Sprintf("%v (%d)", Name(t), uint8(t))
+}
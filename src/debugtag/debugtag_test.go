@@ -0,0 +1,55 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package debugtag
+
+import "testing"
+
+func TestRegister(t *testing.T) {
+	const vectorTag = FirstUserTag + 1
+	err := Register(vectorTag, "vector3", KindMarker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Name(vectorTag) != "vector3" {
+		t.Errorf("Wrong name %v", Name(vectorTag))
+	}
+
+	duplicateErr := Register(vectorTag, "other", KindUnsigned)
+	if duplicateErr == nil {
+		t.Errorf("Expected error when registering taken tag")
+	}
+
+	for _, reserved := range []Tag{0, Uint16, Blob + 1, FirstUserTag - 1} {
+		if Register(reserved, "other", KindUnsigned) == nil {
+			t.Errorf("Expected error when registering reserved tag %d", uint8(reserved))
+		}
+	}
+
+	if Name(FirstUserTag+2) != "unknown" {
+		t.Errorf("Expected unknown")
+	}
+}
@@ -0,0 +1,63 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package debugtag ...
+package debugtag
+
+import "fmt"
+
+// FormatVersion : Layout of the tags in a debug stream
+type FormatVersion uint8
+
+const (
+	// FormatVersion1 : No header, 4 bit tag and 7 bit count
+	FormatVersion1 FormatVersion = 1
+	// FormatVersion2 : Header followed by 8 bit tag and 7 bit count
+	FormatVersion2 FormatVersion = 2
+)
+
+const (
+	// HeaderMarker : First octet of a debug stream header
+	HeaderMarker = 0xb7
+	// HeaderBitCount : Marker, version and flags octets
+	HeaderBitCount = 24
+	// CountBitCount : Bits used for the value bit count after each tag
+	CountBitCount = 7
+
+	// FlagLabeled : Header flag set when each tag is followed by a field label hash
+	FlagLabeled = 0x01
+)
+
+// TagBitCount : Number of bits used for the tag in the format version
+func TagBitCount(version FormatVersion) (uint, error) {
+	switch version {
+	case FormatVersion1:
+		return 4, nil
+	case FormatVersion2:
+		return 8, nil
+	}
+	return 0, fmt.Errorf("debugtag: unknown format version %v", version)
+}
@@ -0,0 +1,218 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package debugtrace walks streams written by debug bit streams without the original decoder
+package debugtrace
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/piot/brook-go/src/debugtag"
+	"github.com/piot/brook-go/src/inbitstream"
+)
+
+// Record : One tagged value found in a debug stream
+type Record struct {
+	Position uint
+	Tag      debugtag.Tag
+	TypeName string
+	Kind     debugtag.Kind
+	BitCount uint
+	Labeled  bool
+	Label    uint16
+	Value    uint64
+	Signed   int64
+	Octets   []byte
+}
+
+// String : One line description of the record
+func (r Record) String() string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("%6d %-10s %3d", r.Position, r.TypeName, r.BitCount))
+	if r.Labeled {
+		builder.WriteString(fmt.Sprintf(" label:%04X", r.Label))
+	}
+	switch r.Kind {
+	case debugtag.KindUnsigned:
+		builder.WriteString(fmt.Sprintf(" %v (0x%X)", r.Value, r.Value))
+	case debugtag.KindSigned:
+		builder.WriteString(fmt.Sprintf(" %v", r.Signed))
+	case debugtag.KindString:
+		builder.WriteString(fmt.Sprintf(" %q", string(r.Octets)))
+	case debugtag.KindBlob:
+		builder.WriteString(fmt.Sprintf(" [% X]", r.Octets))
+	}
+	return builder.String()
+}
+
+// Decode : Walks a debug stream written with the format version and returns every tagged value.
+// Version 1 streams have no header. Later versions must start with a header for that version,
+// and the labeling is taken from its flags. Values written without tags (raw bits) can not be walked.
+func Decode(octets []byte, bitCount uint, version debugtag.FormatVersion) ([]Record, error) {
+	if version == debugtag.FormatVersion1 {
+		return DecodeWithFormat(octets, bitCount, version, false)
+	}
+	in := inbitstream.New(octets, bitCount)
+	marker, markerErr := in.ReadBits(8)
+	if markerErr != nil {
+		return nil, markerErr
+	}
+	if marker != debugtag.HeaderMarker {
+		return nil, &inbitstream.InvalidHeaderError{Marker: marker, Tell: 0}
+	}
+	headerVersion, versionErr := in.ReadBits(8)
+	if versionErr != nil {
+		return nil, versionErr
+	}
+	if debugtag.FormatVersion(headerVersion) != version {
+		return nil, fmt.Errorf("debugtrace: expected format version %v but the header has %v", version, headerVersion)
+	}
+	flags, flagsErr := in.ReadBits(8)
+	if flagsErr != nil {
+		return nil, flagsErr
+	}
+	return decode(in, bitCount, version, flags&debugtag.FlagLabeled != 0)
+}
+
+// DecodeWithFormat : Walks a debug stream without a header, using the specified format
+func DecodeWithFormat(octets []byte, bitCount uint, version debugtag.FormatVersion, labeled bool) ([]Record, error) {
+	return decode(inbitstream.New(octets, bitCount), bitCount, version, labeled)
+}
+
+func decode(in *inbitstream.InBitStreamImpl, bitCount uint, version debugtag.FormatVersion, labeled bool) ([]Record, error) {
+	tagBitCount, versionErr := debugtag.TagBitCount(version)
+	if versionErr != nil {
+		return nil, versionErr
+	}
+	var records []Record
+	for bitCount-in.Tell() >= tagBitCount+debugtag.CountBitCount {
+		record, recordErr := decodeRecord(in, tagBitCount, labeled)
+		if recordErr != nil {
+			return records, recordErr
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func decodeRecord(in *inbitstream.InBitStreamImpl, tagBitCount uint, labeled bool) (Record, error) {
+	record := Record{Position: in.Tell(), Labeled: labeled}
+	tag, tagErr := in.ReadBits(tagBitCount)
+	if tagErr != nil {
+		return record, tagErr
+	}
+	record.Tag = debugtag.Tag(tag)
+	info, known := debugtag.Lookup(record.Tag)
+	if !known {
+		return record, fmt.Errorf("debugtrace: unknown tag %v at bit %v", tag, record.Position)
+	}
+	record.TypeName = info.Name
+	record.Kind = info.Kind
+
+	count, countErr := in.ReadBits(debugtag.CountBitCount)
+	if countErr != nil {
+		return record, countErr
+	}
+	record.BitCount = uint(count)
+
+	if labeled {
+		label, labelErr := in.ReadBits(inbitstream.DebugLabelBitCount)
+		if labelErr != nil {
+			return record, labelErr
+		}
+		record.Label = uint16(label)
+	}
+
+	var valueErr error
+	switch info.Kind {
+	case debugtag.KindUnsigned:
+		record.Value, valueErr = readValue(in, record.BitCount)
+	case debugtag.KindSigned:
+		record.Signed, valueErr = readSigned(in, record.BitCount)
+	case debugtag.KindString, debugtag.KindBlob:
+		record.Octets, valueErr = in.ReadBlob(record.BitCount, ^uint(0))
+		record.Value = uint64(len(record.Octets))
+	}
+	if valueErr != nil {
+		return record, fmt.Errorf("debugtrace: %v at bit %v: %w", record.TypeName, record.Position, valueErr)
+	}
+	return record, nil
+}
+
+func readValue(in *inbitstream.InBitStreamImpl, bitCount uint) (uint64, error) {
+	if bitCount > 64 {
+		return 0, fmt.Errorf("bit count %v is too large", bitCount)
+	}
+	value := uint64(0)
+	for bitCount > 0 {
+		count := bitCount % 32
+		if count == 0 {
+			count = 32
+		}
+		bitCount -= count
+		part, readErr := in.ReadBits(count)
+		if readErr != nil {
+			return 0, readErr
+		}
+		value = value<<count | uint64(part)
+	}
+	return value, nil
+}
+
+func readSigned(in *inbitstream.InBitStreamImpl, bitCount uint) (int64, error) {
+	if bitCount == 0 {
+		return 0, nil
+	}
+	sign, signErr := in.ReadBits(1)
+	if signErr != nil {
+		return 0, signErr
+	}
+	magnitude, magnitudeErr := readValue(in, bitCount-1)
+	if magnitudeErr != nil {
+		return 0, magnitudeErr
+	}
+	if sign != 0 {
+		return -int64(magnitude), nil
+	}
+	return int64(magnitude), nil
+}
+
+// Text : Formats the records, one line each
+func Text(records []Record) string {
+	var builder strings.Builder
+	for _, record := range records {
+		builder.WriteString(record.String())
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+// Trace : Decodes the debug stream and formats it as text. On failure the text contains the records found before the error
+func Trace(octets []byte, bitCount uint, version debugtag.FormatVersion) (string, error) {
+	records, err := Decode(octets, bitCount, version)
+	return Text(records), err
+}
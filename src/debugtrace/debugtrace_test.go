@@ -0,0 +1,114 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package debugtrace
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/piot/brook-go/src/debugtag"
+	"github.com/piot/brook-go/src/inbitstream"
+	"github.com/piot/brook-go/src/outbitstream"
+)
+
+func TestTraceVersion1(t *testing.T) {
+	out := outbitstream.NewDebugStream(outbitstream.New(1024))
+	out.WriteUint16(0xcafe)
+	out.WriteSignedBits(-12, 9)
+	out.WriteUint64(0x123456789)
+	out.WriteString("hi", 4)
+	out.Close()
+
+	records, err := Decode(out.Octets(), out.Tell(), debugtag.FormatVersion1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("Expected 4 records but got %v", len(records))
+	}
+	if records[0].Tag != debugtag.Uint16 || records[0].Value != 0xcafe {
+		t.Errorf("Wrong first record %v", records[0])
+	}
+	if records[1].Signed != -12 || records[1].BitCount != 9 || records[1].Position != 27 {
+		t.Errorf("Wrong second record %v", records[1])
+	}
+	if records[2].Value != 0x123456789 {
+		t.Errorf("Wrong third record %v", records[2])
+	}
+	if string(records[3].Octets) != "hi" {
+		t.Errorf("Wrong fourth record %v", records[3])
+	}
+}
+
+func TestTraceVersion2Labeled(t *testing.T) {
+	out, _ := outbitstream.NewDebugStreamWithFormat(outbitstream.New(1024), debugtag.FormatVersion2, true)
+	out.Label("ammo")
+	out.WriteUint8(12)
+	out.Close()
+
+	text, err := Trace(out.Octets(), out.Tell(), debugtag.FormatVersion2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text, "uint8") || !strings.Contains(text, " 12 (0xC)") {
+		t.Errorf("Unexpected trace %q", text)
+	}
+}
+
+func TestTraceUnknownTag(t *testing.T) {
+	out := outbitstream.New(1024)
+	out.WriteBits(0xf, 4)
+	out.WriteBits(8, 7)
+	out.WriteBits(0, 8)
+	out.Close()
+
+	_, err := Decode(out.Octets(), out.Tell(), debugtag.FormatVersion1)
+	if err == nil {
+		t.Errorf("Expected error")
+	}
+}
+
+func TestVersionIsNotGuessed(t *testing.T) {
+	// A version 1 stream can start with the header marker, so it must not be read as version 2
+	out := outbitstream.New(1024)
+	out.WriteBits(debugtag.HeaderMarker, 8)
+	out.WriteBits(uint32(debugtag.FormatVersion2), 8)
+	out.WriteBits(0, 8)
+	out.Close()
+	if _, err := Decode(out.Octets(), out.Tell(), debugtag.FormatVersion1); err == nil {
+		t.Errorf("Expected version 1 decoding to fail on unknown tag")
+	}
+
+	version1 := outbitstream.NewDebugStream(outbitstream.New(1024))
+	version1.WriteUint8(12)
+	version1.Close()
+	_, headerErr := Decode(version1.Octets(), version1.Tell(), debugtag.FormatVersion2)
+	if !errors.Is(headerErr, inbitstream.ErrInvalidHeader) {
+		t.Errorf("Expected invalid header for version 1 stream but got %v", headerErr)
+	}
+}
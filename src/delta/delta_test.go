@@ -0,0 +1,159 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package delta
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/piot/brook-go/src/inbitstream"
+	"github.com/piot/brook-go/src/outbitstream"
+)
+
+type avatar struct {
+	Health uint8 `brook:"bits=7"`
+	X      int32 `brook:"range=-1000..1000"`
+	Y      int32 `brook:"range=-1000..1000"`
+	Name   string
+	Items  []uint16 `brook:"max=4"`
+	Cache  int      `brook:"skip"`
+}
+
+func TestStructUnchanged(t *testing.T) {
+	baseline := avatar{Health: 100, X: 10, Y: -10, Name: "brook", Items: []uint16{1, 2}}
+	current := baseline
+	current.Cache = 99
+
+	out := outbitstream.New(64)
+	err := Marshal(out, &current, &baseline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Tell() != 5 {
+		t.Errorf("Expected one changed bit for each of the 5 fields but wrote %v bits", out.Tell())
+	}
+}
+
+func TestStructRoundTrip(t *testing.T) {
+	baseline := avatar{Health: 100, X: 10, Y: -10, Name: "brook", Items: []uint16{1, 2}}
+	current := baseline
+	current.X = 11
+	current.Items = []uint16{1, 2, 3}
+
+	out := outbitstream.New(64)
+	err := Marshal(out, &current, &baseline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 5 changed bits, 11 bits for X, 3 bits length and three 16 bit items
+	expectedBitCount := uint(5 + 11 + 3 + 3*16)
+	if out.Tell() != expectedBitCount {
+		t.Errorf("Expected %v bits but wrote %v", expectedBitCount, out.Tell())
+	}
+
+	var target avatar
+	in := inbitstream.New(out.Octets(), out.Tell())
+	readErr := Unmarshal(in, &target, &baseline)
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	current.Cache = 0
+	if !reflect.DeepEqual(target, current) {
+		t.Errorf("Expected %+v but got %+v", current, target)
+	}
+}
+
+func TestStructInPlace(t *testing.T) {
+	baseline := avatar{Health: 100, Name: "brook"}
+	current := baseline
+	current.Health = 50
+
+	out := outbitstream.New(64)
+	Marshal(out, &current, &baseline)
+
+	readErr := Unmarshal(inbitstream.New(out.Octets(), out.Tell()), &baseline, &baseline)
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	if baseline.Health != 50 || baseline.Name != "brook" {
+		t.Errorf("Unexpected %+v", baseline)
+	}
+}
+
+func TestStructTypeMismatch(t *testing.T) {
+	type other struct{ Health uint8 }
+	out := outbitstream.New(64)
+	if Marshal(out, &avatar{}, &other{}) == nil {
+		t.Errorf("Expected error for different types")
+	}
+	if Marshal(out, avatar{}, &avatar{}) == nil {
+		t.Errorf("Expected error for non pointer")
+	}
+}
+
+var healthField = NewField(
+	func(out outbitstream.OutBitStream, v uint8) error { return out.WriteBits(uint32(v), 7) },
+	func(in inbitstream.InBitStream) (uint8, error) {
+		v, err := in.ReadBits(7)
+		return uint8(v), err
+	})
+
+var itemsField = NewFieldWithEqual(
+	func(out outbitstream.OutBitStream, v []uint16) error {
+		return outbitstream.WriteSlice(out, v, 3, func(out outbitstream.OutBitStream, item uint16) error { return out.WriteUint16(item) })
+	},
+	func(in inbitstream.InBitStream) ([]uint16, error) {
+		return inbitstream.ReadSlice(in, 3, 4, func(in inbitstream.InBitStream) (uint16, error) { return in.ReadUint16() })
+	},
+	func(a []uint16, b []uint16) bool { return reflect.DeepEqual(a, b) })
+
+func TestHandWrittenFields(t *testing.T) {
+	baseline := avatar{Health: 100, Items: []uint16{7}}
+	current := avatar{Health: 100, Items: []uint16{7, 8}}
+
+	out := outbitstream.New(64)
+	if err := healthField.Write(out, current.Health, baseline.Health); err != nil {
+		t.Fatal(err)
+	}
+	if err := itemsField.Write(out, current.Items, baseline.Items); err != nil {
+		t.Fatal(err)
+	}
+	expectedBitCount := uint(1 + 1 + 3 + 2*16)
+	if out.Tell() != expectedBitCount {
+		t.Errorf("Expected %v bits but wrote %v", expectedBitCount, out.Tell())
+	}
+
+	in := inbitstream.New(out.Octets(), out.Tell())
+	health, healthErr := healthField.Read(in, baseline.Health)
+	items, itemsErr := itemsField.Read(in, baseline.Items)
+	if healthErr != nil || itemsErr != nil {
+		t.Fatal(healthErr, itemsErr)
+	}
+	if health != 100 || !reflect.DeepEqual(items, current.Items) {
+		t.Errorf("Unexpected %v %v", health, items)
+	}
+}
@@ -0,0 +1,70 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package delta writes values relative to a previously acknowledged baseline.
+// Every field is preceded by a changed bit and only changed fields are written.
+package delta
+
+import (
+	"github.com/piot/brook-go/src/inbitstream"
+	"github.com/piot/brook-go/src/outbitstream"
+)
+
+// Field : Delta encoding of one field for hand-written serializers
+type Field[T any] struct {
+	write func(out outbitstream.OutBitStream, v T) error
+	read  func(in inbitstream.InBitStream) (T, error)
+	equal func(a T, b T) bool
+}
+
+// NewField : Field for comparable values, e.g. numbers, strings and structs without slices
+func NewField[T comparable](write func(out outbitstream.OutBitStream, v T) error, read func(in inbitstream.InBitStream) (T, error)) Field[T] {
+	return Field[T]{write: write, read: read, equal: func(a T, b T) bool { return a == b }}
+}
+
+// NewFieldWithEqual : Field that uses equal to decide if the value has changed, e.g. for slices
+func NewFieldWithEqual[T any](write func(out outbitstream.OutBitStream, v T) error, read func(in inbitstream.InBitStream) (T, error), equal func(a T, b T) bool) Field[T] {
+	return Field[T]{write: write, read: read, equal: equal}
+}
+
+// Write : Writes the changed bit, followed by the value if it differs from the baseline
+func (f Field[T]) Write(out outbitstream.OutBitStream, v T, baseline T) error {
+	changed := !f.equal(v, baseline)
+	changedErr := outbitstream.WriteBool(out, changed)
+	if changedErr != nil || !changed {
+		return changedErr
+	}
+	return f.write(out, v)
+}
+
+// Read : Reads a value written by Write. Returns the baseline if the value has not changed
+func (f Field[T]) Read(in inbitstream.InBitStream, baseline T) (T, error) {
+	changed, changedErr := inbitstream.ReadBool(in)
+	if changedErr != nil || !changed {
+		return baseline, changedErr
+	}
+	return f.read(in)
+}
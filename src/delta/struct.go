@@ -0,0 +1,99 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package delta ...
+package delta
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/piot/brook-go/src/brookstruct"
+	"github.com/piot/brook-go/src/inbitstream"
+	"github.com/piot/brook-go/src/outbitstream"
+)
+
+func structValues(v interface{}, baseline interface{}) (reflect.Value, reflect.Value, *brookstruct.StructCodec, error) {
+	rv := reflect.ValueOf(v)
+	rb := reflect.ValueOf(baseline)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rb.Kind() != reflect.Ptr || rb.IsNil() {
+		return rv, rb, nil, fmt.Errorf("delta: needs non-nil pointers to structs, not %T and %T", v, baseline)
+	}
+	rv = rv.Elem()
+	rb = rb.Elem()
+	if rv.Type() != rb.Type() {
+		return rv, rb, nil, fmt.Errorf("delta: value %v and baseline %v have different types", rv.Type(), rb.Type())
+	}
+	codec, codecErr := brookstruct.CodecFor(rv.Type())
+	return rv, rb, codec, codecErr
+}
+
+// Marshal : Writes the tagged struct v relative to baseline, using the same `brook` tags as brookstruct.
+// Each top level field gets a changed bit, a changed nested struct is written in full.
+func Marshal(out outbitstream.OutBitStream, v interface{}, baseline interface{}) error {
+	rv, rb, codec, err := structValues(v, baseline)
+	if err != nil {
+		return err
+	}
+	for index := 0; index < codec.FieldCount(); index++ {
+		changed := !codec.FieldEqual(rv, rb, index)
+		changedErr := outbitstream.WriteBool(out, changed)
+		if changedErr != nil {
+			return changedErr
+		}
+		if changed {
+			fieldErr := codec.WriteField(out, rv, index)
+			if fieldErr != nil {
+				return fieldErr
+			}
+		}
+	}
+	return nil
+}
+
+// Unmarshal : Reads a struct written by Marshal into v. Unchanged fields are copied from baseline,
+// so slices and pointers in those fields share memory with the baseline. v and baseline may be the same struct
+func Unmarshal(in inbitstream.InBitStream, v interface{}, baseline interface{}) error {
+	rv, rb, codec, err := structValues(v, baseline)
+	if err != nil {
+		return err
+	}
+	for index := 0; index < codec.FieldCount(); index++ {
+		changed, changedErr := inbitstream.ReadBool(in)
+		if changedErr != nil {
+			return changedErr
+		}
+		if !changed {
+			codec.CopyField(rv, rb, index)
+			continue
+		}
+		fieldErr := codec.ReadField(in, rv, index)
+		if fieldErr != nil {
+			return fieldErr
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package golomb ...
+package golomb
+
+import (
+	"github.com/piot/brook-go/src/inbitstream"
+	"github.com/piot/brook-go/src/outbitstream"
+)
+
+// adaptiveHalveCount : The running sums are halved when this many values have been seen, so that
+// the parameter follows changes in the distribution
+const adaptiveHalveCount = 64
+
+// Adaptive : Rice coder that picks the parameter from the running mean of the values seen so far.
+// The decoder must use a fresh Adaptive created with the same initial mean, updated in the same order as the encoder
+type Adaptive struct {
+	sum   uint64
+	count uint64
+}
+
+// NewAdaptive : Creates an adaptive coder, starting out as if one value of initialMean had been seen
+func NewAdaptive(initialMean uint32) *Adaptive {
+	return &Adaptive{sum: uint64(initialMean), count: 1}
+}
+
+// Parameter : The Rice parameter used for the next value, the smallest k where count<<k reaches the sum
+func (a *Adaptive) Parameter() uint {
+	k := uint(0)
+	for k < MaxRiceParameter && a.count<<k < a.sum {
+		k++
+	}
+	return k
+}
+
+func (a *Adaptive) update(v uint32) {
+	a.sum += uint64(v)
+	a.count++
+	if a.count >= adaptiveHalveCount {
+		a.sum >>= 1
+		a.count >>= 1
+	}
+}
+
+// Write : Writes the value with the current parameter and updates the mean
+func (a *Adaptive) Write(targetStream outbitstream.OutBitStream, v uint32) error {
+	writeErr := WriteRice(targetStream, v, a.Parameter())
+	if writeErr != nil {
+		return writeErr
+	}
+	a.update(v)
+	return nil
+}
+
+// Read : Reads a value with the current parameter and updates the mean
+func (a *Adaptive) Read(sourceStream inbitstream.InBitStream) (uint32, error) {
+	v, readErr := ReadRice(sourceStream, a.Parameter())
+	if readErr != nil {
+		return 0, readErr
+	}
+	a.update(v)
+	return v, nil
+}
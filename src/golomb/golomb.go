@@ -0,0 +1,200 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package golomb codes integers that follow a geometric distribution, e.g. gaps between sorted
+// entity IDs and run lengths. Small values get short codes, and the parameter moves the point
+// where the codes start to grow.
+package golomb
+
+import (
+	"fmt"
+	"math/bits"
+
+	"github.com/piot/brook-go/src/inbitstream"
+	"github.com/piot/brook-go/src/outbitstream"
+)
+
+const (
+	// MaxRiceParameter : Largest Rice parameter, where every value is written with 32 bits after the quotient
+	MaxRiceParameter = 31
+
+	// escapeQuotient : Quotients from here and up are written as that many one bits followed by the raw value,
+	// so that huge values with a small parameter can not blow up the stream
+	escapeQuotient = 32
+)
+
+func writeQuotient(targetStream outbitstream.OutBitStream, quotient uint32, v uint32) (bool, error) {
+	escaped := quotient >= escapeQuotient
+	if escaped {
+		quotient = escapeQuotient
+	}
+	// One bit per call, so that the writes match the reads in readQuotient on debug streams
+	for i := uint32(0); i < quotient; i++ {
+		oneErr := targetStream.WriteBits(1, 1)
+		if oneErr != nil {
+			return false, oneErr
+		}
+	}
+	if escaped {
+		return true, targetStream.WriteBits(v, 32)
+	}
+	return false, targetStream.WriteBits(0, 1)
+}
+
+func readQuotient(sourceStream inbitstream.InBitStream) (uint32, bool, error) {
+	quotient := uint32(0)
+	for quotient < escapeQuotient {
+		bit, readErr := sourceStream.ReadBits(1)
+		if readErr != nil {
+			return 0, false, readErr
+		}
+		if bit == 0 {
+			return quotient, false, nil
+		}
+		quotient++
+	}
+	return quotient, true, nil
+}
+
+// RiceBitCount : Number of bits WriteRice uses for the value
+func RiceBitCount(v uint32, k uint) uint {
+	quotient := v >> k
+	if quotient >= escapeQuotient {
+		return escapeQuotient + 32
+	}
+	return uint(quotient) + 1 + k
+}
+
+// WriteRice : Writes the value with Rice parameter k, as v>>k in unary followed by the k lowest bits
+func WriteRice(targetStream outbitstream.OutBitStream, v uint32, k uint) error {
+	if k > MaxRiceParameter {
+		return fmt.Errorf("golomb: rice parameter %v is larger than %v", k, MaxRiceParameter)
+	}
+	escaped, quotientErr := writeQuotient(targetStream, v>>k, v)
+	if quotientErr != nil || escaped || k == 0 {
+		return quotientErr
+	}
+	return targetStream.WriteBits(v&((1<<k)-1), k)
+}
+
+// ReadRice : Reads a value written by WriteRice with the same parameter
+func ReadRice(sourceStream inbitstream.InBitStream, k uint) (uint32, error) {
+	if k > MaxRiceParameter {
+		return 0, fmt.Errorf("golomb: rice parameter %v is larger than %v", k, MaxRiceParameter)
+	}
+	quotient, escaped, quotientErr := readQuotient(sourceStream)
+	if quotientErr != nil {
+		return 0, quotientErr
+	}
+	if escaped {
+		return sourceStream.ReadBits(32)
+	}
+	if k == 0 {
+		return quotient, nil
+	}
+	remainder, remainderErr := sourceStream.ReadBits(k)
+	if remainderErr != nil {
+		return 0, remainderErr
+	}
+	return quotient<<k | remainder, nil
+}
+
+// WriteGolomb : Writes the value with Golomb parameter m, as v/m in unary followed by v%m in truncated binary.
+// Rice coding is the special case where m is a power of two
+func WriteGolomb(targetStream outbitstream.OutBitStream, v uint32, m uint32) error {
+	if m == 0 {
+		return fmt.Errorf("golomb: parameter must be at least 1")
+	}
+	escaped, quotientErr := writeQuotient(targetStream, v/m, v)
+	if quotientErr != nil || escaped || m == 1 {
+		return quotientErr
+	}
+	remainder := v % m
+	bitCount := uint(bits.Len32(m - 1))
+	cutoff := uint32(1)<<bitCount - m
+	if remainder < cutoff {
+		return targetStream.WriteBits(remainder, bitCount-1)
+	}
+	// Split the same way as ReadGolomb reads it
+	extended := remainder + cutoff
+	highErr := targetStream.WriteBits(extended>>1, bitCount-1)
+	if highErr != nil {
+		return highErr
+	}
+	return targetStream.WriteBits(extended&1, 1)
+}
+
+// ReadGolomb : Reads a value written by WriteGolomb with the same parameter
+func ReadGolomb(sourceStream inbitstream.InBitStream, m uint32) (uint32, error) {
+	if m == 0 {
+		return 0, fmt.Errorf("golomb: parameter must be at least 1")
+	}
+	quotient, escaped, quotientErr := readQuotient(sourceStream)
+	if quotientErr != nil {
+		return 0, quotientErr
+	}
+	if escaped {
+		return sourceStream.ReadBits(32)
+	}
+	if m == 1 {
+		return quotient, nil
+	}
+	bitCount := uint(bits.Len32(m - 1))
+	cutoff := uint32(1)<<bitCount - m
+	remainder, remainderErr := sourceStream.ReadBits(bitCount - 1)
+	if remainderErr != nil {
+		return 0, remainderErr
+	}
+	if remainder >= cutoff {
+		lowestBit, lowestErr := sourceStream.ReadBits(1)
+		if lowestErr != nil {
+			return 0, lowestErr
+		}
+		remainder = (remainder<<1 | lowestBit) - cutoff
+	}
+	v := uint64(quotient)*uint64(m) + uint64(remainder)
+	if v > 0xffffffff {
+		return 0, fmt.Errorf("golomb: value does not fit in 32 bits")
+	}
+	return uint32(v), nil
+}
+
+// BestRiceParameter : The Rice parameter that codes all the values with the fewest bits
+func BestRiceParameter(values []uint32) uint {
+	bestK := uint(0)
+	bestBitCount := ^uint64(0)
+	for k := uint(0); k <= MaxRiceParameter; k++ {
+		bitCount := uint64(0)
+		for _, v := range values {
+			bitCount += uint64(RiceBitCount(v, k))
+		}
+		if bitCount < bestBitCount {
+			bestK = k
+			bestBitCount = bitCount
+		}
+	}
+	return bestK
+}
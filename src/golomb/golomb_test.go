@@ -0,0 +1,225 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package golomb
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/piot/brook-go/src/inbitstream"
+	"github.com/piot/brook-go/src/outbitstream"
+)
+
+func geometricValues(count int, mean float64) []uint32 {
+	random := rand.New(rand.NewSource(46))
+	values := make([]uint32, count)
+	for i := range values {
+		values[i] = uint32(random.ExpFloat64() * mean)
+	}
+	return values
+}
+
+func TestRiceCode(t *testing.T) {
+	out := outbitstream.New(16)
+	err := WriteRice(out, 9, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 9 >> 2 = 2 in unary (110) followed by 9 & 3 = 1 in two bits (01)
+	in := inbitstream.New(out.Octets(), out.Tell())
+	if out.Tell() != 5 {
+		t.Fatalf("Expected 5 bits but got %v", out.Tell())
+	}
+	v, _ := in.ReadBits(5)
+	if v != 0x19 {
+		t.Errorf("Expected 11001 but got %b", v)
+	}
+}
+
+func TestGolombTruncatedBinary(t *testing.T) {
+	// m = 5 uses two bits for remainders 0..2 and three bits for 3..4
+	expectedBitCounts := []uint{3, 3, 3, 4, 4, 4, 4, 4, 5, 5}
+	for v, expected := range expectedBitCounts {
+		out := outbitstream.New(16)
+		err := WriteGolomb(out, uint32(v), 5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out.Tell() != expected {
+			t.Errorf("Value %v: expected %v bits but got %v", v, expected, out.Tell())
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	values := []uint32{0, 1, 2, 3, 4, 5, 7, 8, 31, 100, 1000, 65535, 0xfffffffe, 0xffffffff}
+	parameters := []uint32{1, 2, 3, 5, 7, 8, 100, 1 << 20, 0xffffffff}
+	out := outbitstream.New(4096)
+	for _, m := range parameters {
+		for _, v := range values {
+			if err := WriteGolomb(out, v, m); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	for k := uint(0); k <= MaxRiceParameter; k++ {
+		for _, v := range values {
+			if err := WriteRice(out, v, k); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	in := inbitstream.New(out.Octets(), out.Tell())
+	for _, m := range parameters {
+		for _, v := range values {
+			readValue, err := ReadGolomb(in, m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if readValue != v {
+				t.Errorf("Golomb %v: expected %v but got %v", m, v, readValue)
+			}
+		}
+	}
+	for k := uint(0); k <= MaxRiceParameter; k++ {
+		for _, v := range values {
+			readValue, err := ReadRice(in, k)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if readValue != v {
+				t.Errorf("Rice %v: expected %v but got %v", k, v, readValue)
+			}
+		}
+	}
+	if !in.IsEOF() {
+		t.Errorf("Expected all bits to be read")
+	}
+}
+
+func TestRoundTripOverDebugStream(t *testing.T) {
+	values := []uint32{0, 1, 5, 20, 1000, 0xffffffff}
+	out := outbitstream.NewDebugStream(outbitstream.New(4096))
+	for _, v := range values {
+		if err := WriteRice(out, v, 2); err != nil {
+			t.Fatal(err)
+		}
+		if err := WriteGolomb(out, v, 5); err != nil {
+			t.Fatal(err)
+		}
+		if err := WriteGolomb(out, v, 2); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	in := inbitstream.NewDebugStream(inbitstream.New(out.Octets(), out.Tell()))
+	for _, v := range values {
+		riceValue, riceErr := ReadRice(in, 2)
+		if riceErr != nil {
+			t.Fatal(riceErr)
+		}
+		golombValue, golombErr := ReadGolomb(in, 5)
+		if golombErr != nil {
+			t.Fatal(golombErr)
+		}
+		evenValue, evenErr := ReadGolomb(in, 2)
+		if evenErr != nil {
+			t.Fatal(evenErr)
+		}
+		if riceValue != v || golombValue != v || evenValue != v {
+			t.Errorf("Expected %v but got %v, %v and %v", v, riceValue, golombValue, evenValue)
+		}
+	}
+}
+
+func TestEscapedValue(t *testing.T) {
+	out := outbitstream.New(16)
+	err := WriteRice(out, 0xffffffff, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Tell() != RiceBitCount(0xffffffff, 0) || out.Tell() != 64 {
+		t.Errorf("Expected escaped value to use 64 bits but got %v", out.Tell())
+	}
+}
+
+func TestInvalidParameter(t *testing.T) {
+	out := outbitstream.New(16)
+	if WriteRice(out, 1, MaxRiceParameter+1) == nil {
+		t.Errorf("Expected error for too large rice parameter")
+	}
+	if WriteGolomb(out, 1, 0) == nil {
+		t.Errorf("Expected error for zero golomb parameter")
+	}
+}
+
+func TestBestRiceParameter(t *testing.T) {
+	values := geometricValues(1000, 40)
+	best := BestRiceParameter(values)
+	if best < 4 || best > 6 {
+		t.Errorf("Expected a parameter around log2(40) but got %v", best)
+	}
+}
+
+func TestAdaptiveFollowsMean(t *testing.T) {
+	small := geometricValues(500, 2)
+	large := geometricValues(500, 3000)
+	values := append(append([]uint32{}, small...), large...)
+
+	encoder := NewAdaptive(16)
+	out := outbitstream.New(8192)
+	for _, v := range values {
+		if err := encoder.Write(out, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if encoder.Parameter() < 9 {
+		t.Errorf("Expected parameter to follow the larger values but got %v", encoder.Parameter())
+	}
+
+	fixedBitCount := uint(0)
+	k := BestRiceParameter(values)
+	for _, v := range values {
+		fixedBitCount += RiceBitCount(v, k)
+	}
+	if out.Tell() >= fixedBitCount {
+		t.Errorf("Expected adaptive coding (%v bits) to beat the best fixed parameter (%v bits)", out.Tell(), fixedBitCount)
+	}
+
+	decoder := NewAdaptive(16)
+	in := inbitstream.New(out.Octets(), out.Tell())
+	for i, v := range values {
+		readValue, err := decoder.Read(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if readValue != v {
+			t.Fatalf("Index %v: expected %v but got %v", i, v, readValue)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package huffman builds canonical Huffman codes from symbol frequencies, for skewed alphabets
+// like chat text and event IDs
+package huffman
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+)
+
+// MaxCodeLength : Longest code length a table can have
+const MaxCodeLength = 31
+
+type node struct {
+	frequency uint64
+	// order keeps the tree deterministic when frequencies are equal
+	order  int
+	symbol int
+	left   *node
+	right  *node
+}
+
+type nodeHeap []*node
+
+func (h nodeHeap) Len() int { return len(h) }
+func (h nodeHeap) Less(i, j int) bool {
+	if h[i].frequency != h[j].frequency {
+		return h[i].frequency < h[j].frequency
+	}
+	return h[i].order < h[j].order
+}
+func (h nodeHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *nodeHeap) Push(x interface{}) { *h = append(*h, x.(*node)) }
+func (h *nodeHeap) Pop() interface{} {
+	old := *h
+	n := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return n
+}
+
+func assignLengths(n *node, depth uint8, lengths []uint8) {
+	if n.left == nil {
+		lengths[n.symbol] = depth
+		return
+	}
+	assignLengths(n.left, depth+1, lengths)
+	assignLengths(n.right, depth+1, lengths)
+}
+
+// codeLengths returns the Huffman code length of each symbol, zero for unused symbols
+func codeLengths(frequencies []uint32) []uint8 {
+	lengths := make([]uint8, len(frequencies))
+	h := &nodeHeap{}
+	for symbol, frequency := range frequencies {
+		if frequency > 0 {
+			*h = append(*h, &node{frequency: uint64(frequency), order: symbol, symbol: symbol})
+		}
+	}
+	switch h.Len() {
+	case 0:
+		return lengths
+	case 1:
+		lengths[(*h)[0].symbol] = 1
+		return lengths
+	}
+	heap.Init(h)
+	order := len(frequencies)
+	for h.Len() > 1 {
+		left := heap.Pop(h).(*node)
+		right := heap.Pop(h).(*node)
+		heap.Push(h, &node{frequency: left.frequency + right.frequency, order: order, left: left, right: right})
+		order++
+	}
+	assignLengths(heap.Pop(h).(*node), 0, lengths)
+	return lengths
+}
+
+// limitLengths shortens codes longer than maxLength and then lengthens the least frequent
+// shorter codes until the code is a valid prefix code again
+func limitLengths(lengths []uint8, frequencies []uint32, maxLength uint8) {
+	kraft := uint64(0)
+	for symbol, length := range lengths {
+		if length > maxLength {
+			lengths[symbol] = maxLength
+		}
+		if lengths[symbol] > 0 {
+			kraft += uint64(1) << (maxLength - lengths[symbol])
+		}
+	}
+	limit := uint64(1) << maxLength
+	if kraft <= limit {
+		return
+	}
+
+	symbols := make([]int, 0, len(lengths))
+	for symbol, length := range lengths {
+		if length > 0 {
+			symbols = append(symbols, symbol)
+		}
+	}
+	sort.SliceStable(symbols, func(i, j int) bool {
+		return frequencies[symbols[i]] < frequencies[symbols[j]]
+	})
+	for kraft > limit {
+		// Lengthening the longest code below the limit costs the least
+		best := -1
+		for _, symbol := range symbols {
+			length := lengths[symbol]
+			if length < maxLength && (best < 0 || length > lengths[best]) {
+				best = symbol
+			}
+		}
+		kraft -= uint64(1) << (maxLength - lengths[best] - 1)
+		lengths[best]++
+	}
+}
+
+// Build : Creates a canonical code from symbol frequencies. Symbols with frequency zero get no code.
+// No code is longer than maxLength bits
+func Build(frequencies []uint32, maxLength uint) (*Table, error) {
+	if maxLength < 1 || maxLength > MaxCodeLength {
+		return nil, fmt.Errorf("huffman: max code length must be 1..%v", MaxCodeLength)
+	}
+	usedCount := 0
+	for _, frequency := range frequencies {
+		if frequency > 0 {
+			usedCount++
+		}
+	}
+	if usedCount == 0 {
+		return nil, fmt.Errorf("huffman: all frequencies are zero")
+	}
+	if uint64(usedCount) > uint64(1)<<maxLength {
+		return nil, fmt.Errorf("huffman: %v symbols do not fit in codes of %v bits", usedCount, maxLength)
+	}
+	lengths := codeLengths(frequencies)
+	limitLengths(lengths, frequencies, uint8(maxLength))
+	return NewTable(lengths)
+}
+
+// CountFrequencies : Counts how often each symbol occurs, for symbols in the range 0..symbolCount-1
+func CountFrequencies(symbols []uint32, symbolCount int) ([]uint32, error) {
+	frequencies := make([]uint32, symbolCount)
+	for _, symbol := range symbols {
+		if int(symbol) >= symbolCount {
+			return nil, fmt.Errorf("huffman: symbol %v is outside the alphabet of %v symbols", symbol, symbolCount)
+		}
+		frequencies[symbol]++
+	}
+	return frequencies, nil
+}
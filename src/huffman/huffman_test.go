@@ -0,0 +1,275 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package huffman
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/piot/brook-go/src/inbitstream"
+	"github.com/piot/brook-go/src/outbitstream"
+)
+
+const chat = "hello there, how are you doing today? the weather is nice and the game is fun to play"
+
+func chatSymbols() []uint32 {
+	symbols := make([]uint32, len(chat))
+	for i := range chat {
+		symbols[i] = uint32(chat[i])
+	}
+	return symbols
+}
+
+func TestCanonicalCodes(t *testing.T) {
+	table, err := NewTable([]uint8{2, 1, 3, 3, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Length 1: symbol 1 = 0, length 2: symbol 0 = 10, length 3: symbols 2 and 3 = 110, 111
+	expected := []uint32{2, 0, 6, 7}
+	for symbol, code := range expected {
+		if table.codes[symbol] != code {
+			t.Errorf("Symbol %v: expected code %b but got %b", symbol, code, table.codes[symbol])
+		}
+	}
+	if table.CodeLength(4) != 0 || table.CodeLength(99) != 0 {
+		t.Errorf("Expected unused symbols to have no code")
+	}
+}
+
+func TestInvalidLengths(t *testing.T) {
+	if _, err := NewTable([]uint8{1, 1, 1}); err == nil {
+		t.Errorf("Expected error for over-subscribed code")
+	}
+	if _, err := NewTable([]uint8{0, 0}); err == nil {
+		t.Errorf("Expected error for empty table")
+	}
+}
+
+func roundTrip(t *testing.T, table *Table, symbols []uint32) uint {
+	out := outbitstream.New(4096)
+	tableErr := WriteTable(out, table)
+	if tableErr != nil {
+		t.Fatal(tableErr)
+	}
+	tableBitCount := out.Tell()
+	for _, symbol := range symbols {
+		if err := table.Write(out, symbol); err != nil {
+			t.Fatal(err)
+		}
+	}
+	payloadBitCount := out.Tell() - tableBitCount
+
+	decoders := map[string]func(table *Table, in inbitstream.InBitStream, symbols []uint32) error{
+		"Read":              readEach((*Table).Read),
+		"readOneBitAtATime": readEach((*Table).readOneBitAtATime),
+		"ReadSymbols":       (*Table).ReadSymbols,
+	}
+	for name, decode := range decoders {
+		in := inbitstream.New(out.Octets(), out.Tell())
+		readTable, readTableErr := ReadTable(in)
+		if readTableErr != nil {
+			t.Fatal(readTableErr)
+		}
+		readSymbols := make([]uint32, len(symbols))
+		if err := decode(readTable, in, readSymbols); err != nil {
+			t.Fatalf("%v: %v", name, err)
+		}
+		for i, expected := range symbols {
+			if readSymbols[i] != expected {
+				t.Fatalf("%v index %v: expected %v but got %v", name, i, expected, readSymbols[i])
+			}
+		}
+		if !in.IsEOF() {
+			t.Errorf("%v: expected to read all bits", name)
+		}
+	}
+	return payloadBitCount
+}
+
+func readEach(read func(table *Table, in inbitstream.InBitStream) (uint32, error)) func(table *Table, in inbitstream.InBitStream, symbols []uint32) error {
+	return func(table *Table, in inbitstream.InBitStream, symbols []uint32) error {
+		for i := range symbols {
+			symbol, err := read(table, in)
+			if err != nil {
+				return err
+			}
+			symbols[i] = symbol
+		}
+		return nil
+	}
+}
+
+func TestChatText(t *testing.T) {
+	symbols := chatSymbols()
+	frequencies, _ := CountFrequencies(symbols, 256)
+	table, err := Build(frequencies, 15)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payloadBitCount := roundTrip(t, table, symbols)
+	if payloadBitCount >= uint(len(symbols))*5 {
+		t.Errorf("Expected less than 5 bits per character but used %v bits for %v characters", payloadBitCount, len(symbols))
+	}
+}
+
+func TestLengthLimit(t *testing.T) {
+	// Fibonacci frequencies give the most unbalanced tree
+	frequencies := make([]uint32, 20)
+	a, b := uint32(1), uint32(1)
+	for i := range frequencies {
+		frequencies[i] = a
+		a, b = b, a+b
+	}
+	unlimited, _ := Build(frequencies, MaxCodeLength)
+	if unlimited.maxLength != 19 {
+		t.Errorf("Expected longest code of 19 bits but got %v", unlimited.maxLength)
+	}
+	table, err := Build(frequencies, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.maxLength > 8 {
+		t.Errorf("Expected codes of at most 8 bits but got %v", table.maxLength)
+	}
+
+	random := rand.New(rand.NewSource(3))
+	symbols := make([]uint32, 500)
+	for i := range symbols {
+		symbols[i] = uint32(random.Intn(len(frequencies)))
+	}
+	roundTrip(t, table, symbols)
+	// Codes longer than the lookup table are decoded one length at a time
+	roundTrip(t, unlimited, symbols)
+}
+
+func TestRoundTripOverDebugStream(t *testing.T) {
+	symbols := chatSymbols()
+	frequencies, _ := CountFrequencies(symbols, 256)
+	table, _ := Build(frequencies, 15)
+	out := outbitstream.NewDebugStream(outbitstream.New(4096))
+	for _, symbol := range symbols {
+		if err := table.Write(out, symbol); err != nil {
+			t.Fatal(err)
+		}
+	}
+	in := inbitstream.NewDebugStream(inbitstream.New(out.Octets(), out.Tell()))
+	readSymbols := make([]uint32, len(symbols))
+	if err := table.ReadSymbols(in, readSymbols); err != nil {
+		t.Fatal(err)
+	}
+	for i, symbol := range symbols {
+		if readSymbols[i] != symbol {
+			t.Fatalf("Symbol %v: expected %v but got %v", i, symbol, readSymbols[i])
+		}
+	}
+}
+
+func TestReadSymbolsPastEnd(t *testing.T) {
+	table, _ := Build([]uint32{1, 2, 3, 4}, 8)
+	out := outbitstream.New(64)
+	for _, symbol := range []uint32{3, 0, 1} {
+		table.Write(out, symbol)
+	}
+	symbols := make([]uint32, 10)
+	err := table.ReadSymbols(inbitstream.New(out.Octets(), out.Tell()), symbols)
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Expected EOF but got %v", err)
+	}
+}
+
+func TestSingleSymbol(t *testing.T) {
+	table, err := Build([]uint32{0, 0, 5}, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roundTrip(t, table, []uint32{2, 2, 2})
+}
+
+func TestBuildErrors(t *testing.T) {
+	if _, err := Build([]uint32{0, 0}, 8); err == nil {
+		t.Errorf("Expected error for no used symbols")
+	}
+	if _, err := Build([]uint32{1, 1, 1}, 1); err == nil {
+		t.Errorf("Expected error for too many symbols for the length limit")
+	}
+	if _, err := CountFrequencies([]uint32{300}, 256); err == nil {
+		t.Errorf("Expected error for symbol outside alphabet")
+	}
+	table, _ := Build([]uint32{1, 0}, 4)
+	if table.Write(outbitstream.New(16), 1) == nil {
+		t.Errorf("Expected error for symbol without code")
+	}
+}
+
+func benchmarkRead(b *testing.B, read func(table *Table, in inbitstream.InBitStream) (uint32, error)) {
+	symbols := chatSymbols()
+	frequencies, _ := CountFrequencies(symbols, 256)
+	table, _ := Build(frequencies, 15)
+	out := outbitstream.New(4096)
+	for _, symbol := range symbols {
+		table.Write(out, symbol)
+	}
+	octets := out.Octets()
+	bitCount := out.Tell()
+	b.SetBytes(int64(len(symbols)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		in := inbitstream.New(octets, bitCount)
+		for range symbols {
+			read(table, in)
+		}
+	}
+}
+
+func BenchmarkRead(b *testing.B) {
+	benchmarkRead(b, (*Table).Read)
+}
+
+func BenchmarkReadOneBitAtATime(b *testing.B) {
+	benchmarkRead(b, (*Table).readOneBitAtATime)
+}
+
+func BenchmarkReadSymbols(b *testing.B) {
+	symbols := chatSymbols()
+	frequencies, _ := CountFrequencies(symbols, 256)
+	table, _ := Build(frequencies, 15)
+	out := outbitstream.New(4096)
+	for _, symbol := range symbols {
+		table.Write(out, symbol)
+	}
+	octets := out.Octets()
+	bitCount := out.Tell()
+	readSymbols := make([]uint32, len(symbols))
+	b.SetBytes(int64(len(symbols)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		table.ReadSymbols(inbitstream.New(octets, bitCount), readSymbols)
+	}
+}
@@ -0,0 +1,112 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package huffman ...
+package huffman
+
+import (
+	"fmt"
+	"math/bits"
+
+	"github.com/piot/brook-go/src/bitset"
+	"github.com/piot/brook-go/src/inbitstream"
+	"github.com/piot/brook-go/src/outbitstream"
+)
+
+const (
+	symbolCountBitCount  = 16
+	maxLengthBitCount    = 5
+	maxSerializedSymbols = 1 << symbolCountBitCount
+)
+
+// WriteTable : Writes the table compactly: alphabet size, longest code length, which symbols are used
+// and the code length of each used symbol
+func WriteTable(out outbitstream.OutBitStream, t *Table) error {
+	if len(t.lengths) > maxSerializedSymbols {
+		return fmt.Errorf("huffman: can not write tables with more than %v symbols", maxSerializedSymbols)
+	}
+	countErr := out.WriteBits(uint32(len(t.lengths)-1), symbolCountBitCount)
+	if countErr != nil {
+		return countErr
+	}
+	maxLengthErr := out.WriteBits(uint32(t.maxLength), maxLengthBitCount)
+	if maxLengthErr != nil {
+		return maxLengthErr
+	}
+	used := bitset.New(uint(len(t.lengths)))
+	for symbol, length := range t.lengths {
+		if length > 0 {
+			used.Set(uint(symbol))
+		}
+	}
+	usedErr := bitset.WriteBitset(out, used)
+	if usedErr != nil {
+		return usedErr
+	}
+	lengthBitCount := uint(bits.Len8(t.maxLength - 1))
+	for _, length := range t.lengths {
+		if length == 0 {
+			continue
+		}
+		lengthErr := out.WriteBits(uint32(length-1), lengthBitCount)
+		if lengthErr != nil {
+			return lengthErr
+		}
+	}
+	return nil
+}
+
+// ReadTable : Reads a table written by WriteTable
+func ReadTable(in inbitstream.InBitStream) (*Table, error) {
+	countMinusOne, countErr := in.ReadBits(symbolCountBitCount)
+	if countErr != nil {
+		return nil, countErr
+	}
+	maxLength, maxLengthErr := in.ReadBits(maxLengthBitCount)
+	if maxLengthErr != nil {
+		return nil, maxLengthErr
+	}
+	if maxLength == 0 {
+		return nil, fmt.Errorf("huffman: max code length must be at least 1")
+	}
+	used, usedErr := bitset.ReadBitset(in, uint(countMinusOne)+1)
+	if usedErr != nil {
+		return nil, usedErr
+	}
+	lengths := make([]uint8, countMinusOne+1)
+	lengthBitCount := uint(bits.Len8(uint8(maxLength) - 1))
+	for symbol := range lengths {
+		if !used.Test(uint(symbol)) {
+			continue
+		}
+		lengthMinusOne, lengthErr := in.ReadBits(lengthBitCount)
+		if lengthErr != nil {
+			return nil, lengthErr
+		}
+		lengths[symbol] = uint8(lengthMinusOne) + 1
+	}
+	return NewTable(lengths)
+}
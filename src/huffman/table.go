@@ -0,0 +1,289 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package huffman ...
+package huffman
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/piot/brook-go/src/inbitstream"
+	"github.com/piot/brook-go/src/outbitstream"
+)
+
+// Table : Canonical Huffman code. Codes of the same length are consecutive and ordered by symbol,
+// so the code is fully described by the code length of each symbol
+type Table struct {
+	lengths []uint8
+	codes   []uint32
+
+	minLength uint8
+	maxLength uint8
+	// Per code length: the first code, the number of codes and where its symbols start in sorted
+	firstCode []uint32
+	count     []uint32
+	offset    []uint32
+	sorted    []uint32
+
+	// lookup is indexed by the next lookupBits bits and holds symbol<<8 | code length,
+	// or zero when the code is longer than lookupBits
+	lookupBits uint
+	lookup     []uint32
+}
+
+// maxLookupBits : Codes up to this length are decoded with one table lookup
+const maxLookupBits = 11
+
+// NewTable : Creates the canonical code from the code length of each symbol, zero for unused symbols
+func NewTable(lengths []uint8) (*Table, error) {
+	t := &Table{lengths: append([]uint8{}, lengths...), codes: make([]uint32, len(lengths))}
+	for symbol, length := range lengths {
+		if length > MaxCodeLength {
+			return nil, fmt.Errorf("huffman: symbol %v has code length %v, max is %v", symbol, length, MaxCodeLength)
+		}
+		if length == 0 {
+			continue
+		}
+		t.sorted = append(t.sorted, uint32(symbol))
+		if t.minLength == 0 || length < t.minLength {
+			t.minLength = length
+		}
+		if length > t.maxLength {
+			t.maxLength = length
+		}
+	}
+	if len(t.sorted) == 0 {
+		return nil, fmt.Errorf("huffman: table has no symbols")
+	}
+	sort.SliceStable(t.sorted, func(i, j int) bool {
+		return lengths[t.sorted[i]] < lengths[t.sorted[j]]
+	})
+
+	t.firstCode = make([]uint32, t.maxLength+1)
+	t.count = make([]uint32, t.maxLength+1)
+	t.offset = make([]uint32, t.maxLength+1)
+	for _, symbol := range t.sorted {
+		t.count[lengths[symbol]]++
+	}
+	code := uint64(0)
+	offset := uint32(0)
+	for length := uint8(1); length <= t.maxLength; length++ {
+		code <<= 1
+		t.firstCode[length] = uint32(code)
+		t.offset[length] = offset
+		code += uint64(t.count[length])
+		offset += t.count[length]
+		if code > uint64(1)<<length {
+			return nil, fmt.Errorf("huffman: code lengths are not a valid prefix code")
+		}
+	}
+	for index, symbol := range t.sorted {
+		length := lengths[symbol]
+		t.codes[symbol] = t.firstCode[length] + uint32(index) - t.offset[length]
+	}
+	t.buildLookup()
+	return t, nil
+}
+
+func (t *Table) buildLookup() {
+	t.lookupBits = uint(t.maxLength)
+	if t.lookupBits > maxLookupBits {
+		t.lookupBits = maxLookupBits
+	}
+	t.lookup = make([]uint32, 1<<t.lookupBits)
+	for symbol, length := range t.lengths {
+		if length == 0 || uint(length) > t.lookupBits {
+			continue
+		}
+		// Every index that starts with the code maps to the symbol
+		unusedBitCount := t.lookupBits - uint(length)
+		first := t.codes[symbol] << unusedBitCount
+		entry := uint32(symbol)<<8 | uint32(length)
+		for i := uint32(0); i < 1<<unusedBitCount; i++ {
+			t.lookup[first+i] = entry
+		}
+	}
+}
+
+// SymbolCount : Size of the alphabet, including symbols without a code
+func (t *Table) SymbolCount() int {
+	return len(t.lengths)
+}
+
+// CodeLength : Number of bits used for the symbol, zero if it has no code
+func (t *Table) CodeLength(symbol uint32) uint {
+	if int(symbol) >= len(t.lengths) {
+		return 0
+	}
+	return uint(t.lengths[symbol])
+}
+
+// Write : Writes the code for the symbol. Streams other than OutBitStreamImpl, like debug streams,
+// get the code split into the same writes as readOneBitAtATime reads it with
+func (t *Table) Write(out outbitstream.OutBitStream, symbol uint32) error {
+	length := t.CodeLength(symbol)
+	if length == 0 {
+		return fmt.Errorf("huffman: symbol %v has no code", symbol)
+	}
+	code := t.codes[symbol]
+	_, isImpl := out.(*outbitstream.OutBitStreamImpl)
+	if isImpl {
+		return out.WriteBits(code, length)
+	}
+	extraBitCount := length - uint(t.minLength)
+	prefixErr := out.WriteBits(code>>extraBitCount, uint(t.minLength))
+	if prefixErr != nil {
+		return prefixErr
+	}
+	for i := extraBitCount; i > 0; i-- {
+		bitErr := out.WriteBits(code>>(i-1)&1, 1)
+		if bitErr != nil {
+			return bitErr
+		}
+	}
+	return nil
+}
+
+// Read : Reads one symbol. Streams that can peek, like InBitStreamImpl, decode codes of up to
+// 11 bits with one table lookup. Longer codes and other streams use readOneBitAtATime
+func (t *Table) Read(in inbitstream.InBitStream) (uint32, error) {
+	peeker, canPeek := in.(*inbitstream.InBitStreamImpl)
+	if canPeek {
+		next, peekErr := peeker.PeekBits(t.lookupBits)
+		if peekErr != nil {
+			return 0, peekErr
+		}
+		if entry := t.lookup[next]; entry != 0 {
+			skipErr := peeker.Skip(uint(entry & 0xff))
+			if skipErr != nil {
+				return 0, skipErr
+			}
+			return entry >> 8, nil
+		}
+	}
+	return t.readOneBitAtATime(in)
+}
+
+// ReadSymbols : Reads len(symbols) symbols. From an InBitStreamImpl the codes are decoded from a
+// local 64-bit bit buffer that is refilled an octet at a time, which is much faster than calling Read
+// for each symbol. The stream is skipped past the last symbol when done
+func (t *Table) ReadSymbols(in inbitstream.InBitStream, symbols []uint32) error {
+	impl, isImpl := in.(*inbitstream.InBitStreamImpl)
+	if !isImpl {
+		for i := range symbols {
+			symbol, readErr := t.Read(in)
+			if readErr != nil {
+				return readErr
+			}
+			symbols[i] = symbol
+		}
+		return nil
+	}
+
+	octets := impl.Octets()
+	start := impl.Tell()
+	nextOctet := int(start / 8)
+	buffer := uint64(0)
+	bufferBitCount := uint(0)
+	// The first octet is read whole and the bits before the start are shifted out
+	skipBitCount := start % 8
+	consumed := uint(0)
+	lookupShift := 64 - t.lookupBits
+	for i := range symbols {
+		// Octets after the end are read as zero, Skip below reports if the codes went past the end
+		for bufferBitCount <= 56 {
+			octet := byte(0)
+			if nextOctet < len(octets) {
+				octet = octets[nextOctet]
+			}
+			buffer |= uint64(octet) << (56 - bufferBitCount)
+			bufferBitCount += 8
+			nextOctet++
+		}
+		buffer <<= skipBitCount
+		bufferBitCount -= skipBitCount
+		skipBitCount = 0
+
+		entry := t.lookup[buffer>>lookupShift]
+		symbol := entry >> 8
+		length := uint(entry & 0xff)
+		if entry == 0 {
+			var found bool
+			symbol, length, found = t.decodeLongCode(buffer)
+			if !found {
+				if skipErr := impl.Skip(consumed + uint(t.maxLength)); skipErr != nil {
+					return &inbitstream.EOFError{Count: uint(t.maxLength), Tell: start + consumed}
+				}
+				return fmt.Errorf("huffman: invalid code 0x%X", buffer>>(64-uint(t.maxLength)))
+			}
+		}
+		buffer <<= length
+		bufferBitCount -= length
+		consumed += length
+		symbols[i] = symbol
+	}
+
+	if skipErr := impl.Skip(consumed); skipErr != nil {
+		return &inbitstream.EOFError{Count: consumed, Tell: start}
+	}
+	return nil
+}
+
+// decodeLongCode : Finds the code at the top of the bit buffer by trying each code length
+func (t *Table) decodeLongCode(buffer uint64) (uint32, uint, bool) {
+	for length := uint(t.minLength); length <= uint(t.maxLength); length++ {
+		code := uint32(buffer >> (64 - length))
+		if index := code - t.firstCode[length]; code >= t.firstCode[length] && index < t.count[length] {
+			return t.sorted[t.offset[length]+index], length, true
+		}
+	}
+	return 0, 0, false
+}
+
+// readOneBitAtATime : The shortest code length is read at once, then one bit at a time
+// until the code matches a code length, so it never reads past the end of the symbol
+func (t *Table) readOneBitAtATime(in inbitstream.InBitStream) (uint32, error) {
+	code, readErr := in.ReadBits(uint(t.minLength))
+	if readErr != nil {
+		return 0, readErr
+	}
+	length := t.minLength
+	for {
+		if index := code - t.firstCode[length]; code >= t.firstCode[length] && index < t.count[length] {
+			return t.sorted[t.offset[length]+index], nil
+		}
+		if length == t.maxLength {
+			return 0, fmt.Errorf("huffman: invalid code 0x%X", code)
+		}
+		bit, bitErr := in.ReadBits(1)
+		if bitErr != nil {
+			return 0, bitErr
+		}
+		code = code<<1 | bit
+		length++
+	}
+}
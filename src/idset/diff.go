@@ -0,0 +1,117 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package idset ...
+package idset
+
+import (
+	"fmt"
+
+	"github.com/piot/brook-go/src/inbitstream"
+	"github.com/piot/brook-go/src/outbitstream"
+)
+
+// Diff : The IDs in current that are not in previous, and the indices in previous of the IDs that are not in current.
+// Both sets must be sorted and unique
+func Diff(previous []uint32, current []uint32) (added []uint32, removedIndices []uint32) {
+	p := 0
+	c := 0
+	for p < len(previous) || c < len(current) {
+		switch {
+		case p == len(previous) || (c < len(current) && current[c] < previous[p]):
+			added = append(added, current[c])
+			c++
+		case c == len(current) || previous[p] < current[c]:
+			removedIndices = append(removedIndices, uint32(p))
+			p++
+		default:
+			p++
+			c++
+		}
+	}
+	return added, removedIndices
+}
+
+// Apply : Creates the current set from the previous set and the changes returned by Diff
+func Apply(previous []uint32, added []uint32, removedIndices []uint32) ([]uint32, error) {
+	if len(removedIndices) > len(previous) {
+		return nil, fmt.Errorf("idset: can not remove %v ids from a set of %v ids", len(removedIndices), len(previous))
+	}
+	current := make([]uint32, 0, len(previous)+len(added)-len(removedIndices))
+	a := 0
+	r := 0
+	for p, id := range previous {
+		if r < len(removedIndices) && removedIndices[r] == uint32(p) {
+			r++
+			continue
+		}
+		for a < len(added) && added[a] < id {
+			current = append(current, added[a])
+			a++
+		}
+		if a < len(added) && added[a] == id {
+			return nil, fmt.Errorf("idset: added id %v is already in the set", id)
+		}
+		current = append(current, id)
+	}
+	if r != len(removedIndices) {
+		return nil, fmt.Errorf("idset: removed index %v is outside the previous set of %v ids", removedIndices[r], len(previous))
+	}
+	return append(current, added[a:]...), nil
+}
+
+// WriteDiff : Writes the changes from previous to current, as the added IDs followed by the indices
+// of the removed IDs in previous. Indices are used since they are smaller and closer together than the IDs
+func WriteDiff(targetStream outbitstream.OutBitStream, previous []uint32, current []uint32) error {
+	previousErr := Validate(previous)
+	if previousErr != nil {
+		return previousErr
+	}
+	currentErr := Validate(current)
+	if currentErr != nil {
+		return currentErr
+	}
+	added, removedIndices := Diff(previous, current)
+	addedErr := WriteSet(targetStream, added)
+	if addedErr != nil {
+		return addedErr
+	}
+	return WriteSet(targetStream, removedIndices)
+}
+
+// ReadDiff : Reads the changes written by WriteDiff and applies them to previous.
+// Fails if more than maxAdded IDs were added
+func ReadDiff(sourceStream inbitstream.InBitStream, previous []uint32, maxAdded uint) ([]uint32, error) {
+	added, addedErr := ReadSet(sourceStream, maxAdded)
+	if addedErr != nil {
+		return nil, addedErr
+	}
+	removedIndices, removedErr := ReadSet(sourceStream, uint(len(previous)))
+	if removedErr != nil {
+		return nil, removedErr
+	}
+	return Apply(previous, added, removedIndices)
+}
@@ -0,0 +1,205 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package idset
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/piot/brook-go/src/inbitstream"
+	"github.com/piot/brook-go/src/outbitstream"
+)
+
+func randomSet(random *rand.Rand, count int, maxID uint32) []uint32 {
+	used := map[uint32]bool{}
+	for len(used) < count {
+		used[uint32(random.Int63n(int64(maxID)))] = true
+	}
+	ids := make([]uint32, 0, count)
+	for id := uint32(0); id < maxID; id++ {
+		if used[id] {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func equal(a []uint32, b []uint32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSetRoundTrip(t *testing.T) {
+	random := rand.New(rand.NewSource(48))
+	sets := [][]uint32{
+		{},
+		{0},
+		{0xffffffff},
+		{0, 0xffffffff},
+		{10, 11, 12, 13, 14},
+		randomSet(random, 200, 4096),
+	}
+	for _, ids := range sets {
+		out := outbitstream.New(1024)
+		err := WriteSet(out, ids)
+		if err != nil {
+			t.Fatal(err)
+		}
+		in := inbitstream.New(out.Octets(), out.Tell())
+		readIDs, readErr := ReadSet(in, 1000)
+		if readErr != nil {
+			t.Fatal(readErr)
+		}
+		if !equal(ids, readIDs) {
+			t.Errorf("Expected %v but got %v", ids, readIDs)
+		}
+	}
+}
+
+func TestSetIsCompact(t *testing.T) {
+	random := rand.New(rand.NewSource(48))
+	ids := randomSet(random, 200, 4096)
+	out := outbitstream.New(1024)
+	err := WriteSet(out, ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// About 20 IDs per gap on average, so the gaps should take around six bits each instead of twelve for the IDs
+	if out.Tell() > 200*7 {
+		t.Errorf("Expected at most %v bits but got %v", 200*7, out.Tell())
+	}
+}
+
+func TestUnsortedSet(t *testing.T) {
+	out := outbitstream.New(64)
+	if WriteSet(out, []uint32{3, 2}) == nil {
+		t.Errorf("Expected error for unsorted set")
+	}
+	if WriteSet(out, []uint32{3, 3}) == nil {
+		t.Errorf("Expected error for duplicate ids")
+	}
+}
+
+func TestMaxCount(t *testing.T) {
+	out := outbitstream.New(64)
+	WriteSet(out, []uint32{1, 2, 3})
+	in := inbitstream.New(out.Octets(), out.Tell())
+	_, err := ReadSet(in, 2)
+	if !errors.Is(err, inbitstream.ErrOverflow) {
+		t.Errorf("Expected ErrOverflow for too many ids but got %v", err)
+	}
+}
+
+func TestSetRoundTripOverDebugStream(t *testing.T) {
+	ids := []uint32{3, 90, 400}
+	out := outbitstream.NewDebugStream(outbitstream.New(64))
+	if err := WriteSet(out, ids); err != nil {
+		t.Fatal(err)
+	}
+	in := inbitstream.NewDebugStream(inbitstream.New(out.Octets(), out.Tell()))
+	readIds, readErr := ReadSet(in, 10)
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	if len(readIds) != len(ids) {
+		t.Fatalf("Expected %v ids but got %v", ids, readIds)
+	}
+	for i := range ids {
+		if readIds[i] != ids[i] {
+			t.Errorf("Index %v: expected %v but got %v", i, ids[i], readIds[i])
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	previous := []uint32{2, 5, 7, 9}
+	current := []uint32{1, 5, 8, 9, 12}
+	added, removedIndices := Diff(previous, current)
+	if !equal(added, []uint32{1, 8, 12}) {
+		t.Errorf("Unexpected added %v", added)
+	}
+	if !equal(removedIndices, []uint32{0, 2}) {
+		t.Errorf("Unexpected removed indices %v", removedIndices)
+	}
+	applied, err := Apply(previous, added, removedIndices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equal(applied, current) {
+		t.Errorf("Expected %v but got %v", current, applied)
+	}
+}
+
+func TestDiffRoundTrip(t *testing.T) {
+	random := rand.New(rand.NewSource(48))
+	previous := randomSet(random, 300, 2000)
+	current := make([]uint32, 0, len(previous))
+	for _, id := range previous {
+		if random.Intn(20) != 0 {
+			current = append(current, id)
+		}
+	}
+	current = append(current, 2000, 2003, 2010)
+
+	out := outbitstream.New(1024)
+	err := WriteDiff(out, previous, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fullOut := outbitstream.New(1024)
+	WriteSet(fullOut, current)
+	if out.Tell() >= fullOut.Tell()/2 {
+		t.Errorf("Expected diff (%v bits) to be much smaller than the full set (%v bits)", out.Tell(), fullOut.Tell())
+	}
+
+	in := inbitstream.New(out.Octets(), out.Tell())
+	readCurrent, readErr := ReadDiff(in, previous, 100)
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	if !equal(readCurrent, current) {
+		t.Errorf("Expected %v but got %v", current, readCurrent)
+	}
+}
+
+func TestApplyInvalid(t *testing.T) {
+	previous := []uint32{2, 5}
+	if _, err := Apply(previous, []uint32{5}, nil); err == nil {
+		t.Errorf("Expected error for adding an existing id")
+	}
+	if _, err := Apply(previous, nil, []uint32{2}); err == nil {
+		t.Errorf("Expected error for removed index outside the set")
+	}
+}
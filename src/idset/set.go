@@ -0,0 +1,150 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package idset codes sorted sets of entity IDs, as the first ID followed by Rice coded gaps,
+// and the changes between two sets as the added and removed IDs.
+package idset
+
+import (
+	"fmt"
+	"math/bits"
+
+	"github.com/piot/brook-go/src/golomb"
+	"github.com/piot/brook-go/src/inbitstream"
+	"github.com/piot/brook-go/src/outbitstream"
+)
+
+const (
+	countRiceParameter = 3
+	parameterBitCount  = 5
+	lengthBitCount     = 6
+)
+
+// Validate : Checks that the IDs are strictly increasing
+func Validate(ids []uint32) error {
+	for i := 1; i < len(ids); i++ {
+		if ids[i] <= ids[i-1] {
+			return fmt.Errorf("idset: id %v at index %v is not larger than the previous id %v", ids[i], i, ids[i-1])
+		}
+	}
+	return nil
+}
+
+// gaps : Distance to the previous ID minus one, which is zero for consecutive IDs
+func gaps(ids []uint32) []uint32 {
+	if len(ids) < 2 {
+		return nil
+	}
+	result := make([]uint32, len(ids)-1)
+	for i := 1; i < len(ids); i++ {
+		result[i-1] = ids[i] - ids[i-1] - 1
+	}
+	return result
+}
+
+// WriteSet : Writes the count, the first ID and the gaps between the IDs, which must be sorted and unique
+func WriteSet(targetStream outbitstream.OutBitStream, ids []uint32) error {
+	validateErr := Validate(ids)
+	if validateErr != nil {
+		return validateErr
+	}
+	countErr := golomb.WriteRice(targetStream, uint32(len(ids)), countRiceParameter)
+	if countErr != nil || len(ids) == 0 {
+		return countErr
+	}
+
+	firstLength := uint(bits.Len32(ids[0]))
+	lengthErr := targetStream.WriteBits(uint32(firstLength), lengthBitCount)
+	if lengthErr != nil {
+		return lengthErr
+	}
+	firstErr := targetStream.WriteBits(ids[0], firstLength)
+	if firstErr != nil || len(ids) == 1 {
+		return firstErr
+	}
+
+	idGaps := gaps(ids)
+	k := golomb.BestRiceParameter(idGaps)
+	parameterErr := targetStream.WriteBits(uint32(k), parameterBitCount)
+	if parameterErr != nil {
+		return parameterErr
+	}
+	for _, gap := range idGaps {
+		gapErr := golomb.WriteRice(targetStream, gap, k)
+		if gapErr != nil {
+			return gapErr
+		}
+	}
+	return nil
+}
+
+// ReadSet : Reads a set written by WriteSet. Fails if the set has more than maxCount IDs
+func ReadSet(sourceStream inbitstream.InBitStream, maxCount uint) ([]uint32, error) {
+	count, countErr := golomb.ReadRice(sourceStream, countRiceParameter)
+	if countErr != nil {
+		return nil, countErr
+	}
+	if uint(count) > maxCount {
+		return nil, &inbitstream.OverflowError{Value: uint64(count), Max: uint64(maxCount), Tell: inbitstream.TellOf(sourceStream)}
+	}
+	ids := make([]uint32, count)
+	if count == 0 {
+		return ids, nil
+	}
+
+	firstLength, lengthErr := sourceStream.ReadBits(lengthBitCount)
+	if lengthErr != nil {
+		return nil, lengthErr
+	}
+	if firstLength > 32 {
+		return nil, &inbitstream.OverflowError{Value: uint64(firstLength), Max: 32, Tell: inbitstream.TellOf(sourceStream)}
+	}
+	first, firstErr := sourceStream.ReadBits(uint(firstLength))
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	ids[0] = first
+	if count == 1 {
+		return ids, nil
+	}
+
+	k, parameterErr := sourceStream.ReadBits(parameterBitCount)
+	if parameterErr != nil {
+		return nil, parameterErr
+	}
+	for i := 1; i < len(ids); i++ {
+		gap, gapErr := golomb.ReadRice(sourceStream, uint(k))
+		if gapErr != nil {
+			return nil, gapErr
+		}
+		next := uint64(ids[i-1]) + uint64(gap) + 1
+		if next > 0xffffffff {
+			return nil, &inbitstream.OverflowError{Value: next, Max: 0xffffffff, Tell: inbitstream.TellOf(sourceStream)}
+		}
+		ids[i] = uint32(next)
+	}
+	return ids, nil
+}
@@ -0,0 +1,94 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package inbitstream ...
+package inbitstream
+
+func readCount(sourceStream InBitStream, countBitCount uint, maxCount uint) (uint, error) {
+	count, countErr := sourceStream.ReadBits(countBitCount)
+	if countErr != nil {
+		return 0, countErr
+	}
+	if uint(count) > maxCount {
+		return 0, &OverflowError{Value: uint64(count), Max: uint64(maxCount), Tell: TellOf(sourceStream)}
+	}
+	return uint(count), nil
+}
+
+// ReadSlice : Reads an item count using countBitCount bits, at most maxCount, followed by each item
+func ReadSlice[T any](sourceStream InBitStream, countBitCount uint, maxCount uint, readItem func(InBitStream) (T, error)) ([]T, error) {
+	count, countErr := readCount(sourceStream, countBitCount, maxCount)
+	if countErr != nil {
+		return nil, countErr
+	}
+	items := make([]T, count)
+	for i := range items {
+		item, itemErr := readItem(sourceStream)
+		if itemErr != nil {
+			return nil, itemErr
+		}
+		items[i] = item
+	}
+	return items, nil
+}
+
+// ReadMap : Reads an entry count using countBitCount bits, at most maxCount, followed by each key and value
+func ReadMap[K comparable, V any](sourceStream InBitStream, countBitCount uint, maxCount uint,
+	readKey func(InBitStream) (K, error), readValue func(InBitStream) (V, error)) (map[K]V, error) {
+	count, countErr := readCount(sourceStream, countBitCount, maxCount)
+	if countErr != nil {
+		return nil, countErr
+	}
+	m := make(map[K]V, count)
+	for i := uint(0); i < count; i++ {
+		key, keyErr := readKey(sourceStream)
+		if keyErr != nil {
+			return nil, keyErr
+		}
+		value, valueErr := readValue(sourceStream)
+		if valueErr != nil {
+			return nil, valueErr
+		}
+		if _, exists := m[key]; exists {
+			return nil, &DuplicateKeyError{Key: key, Tell: TellOf(sourceStream)}
+		}
+		m[key] = value
+	}
+	return m, nil
+}
+
+// ReadOptional : Reads a presence bit, followed by the item if it is present
+func ReadOptional[T any](sourceStream InBitStream, readItem func(InBitStream) (T, error)) (*T, error) {
+	present, presenceErr := ReadBool(sourceStream)
+	if presenceErr != nil || !present {
+		return nil, presenceErr
+	}
+	item, itemErr := readItem(sourceStream)
+	if itemErr != nil {
+		return nil, itemErr
+	}
+	return &item, nil
+}
@@ -0,0 +1,77 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package inbitstream ...
+package inbitstream
+
+import (
+	"hash/fnv"
+	"strings"
+)
+
+// DebugLabelBitCount : Number of bits used for the field label in labeled debug streams
+const DebugLabelBitCount = 16
+
+// DebugLabelPath : Keeps track of the field path for labeled debug streams
+type DebugLabelPath struct {
+	scopes []string
+	leaf   string
+}
+
+// Push : Enters a scope, e.g. a nested struct
+func (p *DebugLabelPath) Push(name string) {
+	p.scopes = append(p.scopes, name)
+}
+
+// Pop : Leaves the latest scope
+func (p *DebugLabelPath) Pop() {
+	if len(p.scopes) > 0 {
+		p.scopes = p.scopes[:len(p.scopes)-1]
+	}
+}
+
+// SetLeaf : Sets the field name for the next write or read
+func (p *DebugLabelPath) SetLeaf(name string) {
+	p.leaf = name
+}
+
+// Take : Returns the full field path and clears the field name
+func (p *DebugLabelPath) Take() string {
+	parts := p.scopes
+	if p.leaf != "" {
+		parts = append(parts[:len(parts):len(parts)], p.leaf)
+	}
+	p.leaf = ""
+	return strings.Join(parts, ".")
+}
+
+// DebugLabelHash : Hash of a field path as stored in labeled debug streams
+func DebugLabelHash(path string) uint16 {
+	h := fnv.New32a()
+	h.Write([]byte(path))
+	sum := h.Sum32()
+	return uint16(sum>>16) ^ uint16(sum)
+}
@@ -26,8 +26,12 @@ SOFTWARE.
 
 package inbitstream
 
-import "fmt"
+import (
+	"fmt"
+	"io"
+)
 
+// EOFError : Tried to read past the end of the stream. Matches io.ErrUnexpectedEOF using errors.Is
 type EOFError struct {
 	Count uint
 	Tell  uint
@@ -36,3 +40,7 @@ type EOFError struct {
 func (e *EOFError) Error() string {
 	return fmt.Sprintf("Passed end of stream, tried to read %v at position:%v", e.Count, e.Tell)
 }
+
+func (e *EOFError) Is(target error) bool {
+	return target == io.ErrUnexpectedEOF
+}
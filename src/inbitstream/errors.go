@@ -0,0 +1,204 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package inbitstream ...
+package inbitstream
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/piot/brook-go/src/debugtag"
+)
+
+var (
+	// ErrOverflow : A length or count read from the stream is larger than allowed
+	ErrOverflow = errors.New("overflow")
+	// ErrInvalidBitCount : Too many bits requested in one read
+	ErrInvalidBitCount = errors.New("invalid bit count")
+	// ErrTypeMismatch : A debug stream type tag, bit count or label did not match
+	ErrTypeMismatch = errors.New("type mismatch")
+	// ErrSeekOutOfRange : Seek outside the stream
+	ErrSeekOutOfRange = errors.New("seek out of range")
+	// ErrBufferFull : The target octets can not hold the bits to read
+	ErrBufferFull = errors.New("buffer full")
+	// ErrInvalidUTF8 : A string read from the stream is not valid UTF-8
+	ErrInvalidUTF8 = errors.New("invalid UTF-8")
+	// ErrInvalidHeader : The debug stream header does not start with the header marker
+	ErrInvalidHeader = errors.New("invalid header")
+	// ErrDuplicateKey : A map read from the stream has the same key more than once
+	ErrDuplicateKey = errors.New("duplicate key")
+)
+
+// InvalidBitCountError : Too many bits requested in one read
+type InvalidBitCountError struct {
+	Count uint
+	Max   uint
+	Tell  uint
+}
+
+func (e *InvalidBitCountError) Error() string {
+	return fmt.Sprintf("Max %v bits to read, tried to read %v at position:%v", e.Max, e.Count, e.Tell)
+}
+
+func (e *InvalidBitCountError) Is(target error) bool {
+	return target == ErrInvalidBitCount
+}
+
+// OverflowError : A length or count read from the stream is larger than allowed
+type OverflowError struct {
+	Value uint64
+	Max   uint64
+	Tell  uint
+}
+
+func (e *OverflowError) Error() string {
+	return fmt.Sprintf("length %v exceeds max length %v at position:%v", e.Value, e.Max, e.Tell)
+}
+
+func (e *OverflowError) Is(target error) bool {
+	return target == ErrOverflow
+}
+
+// SeekError : Seek to a position other than zero, which InBitStreamImpl does not support
+type SeekError struct {
+	Position uint
+	BitCount uint
+}
+
+func (e *SeekError) Error() string {
+	if e.Position <= e.BitCount {
+		return fmt.Sprintf("can only seek to zero, not %v", e.Position)
+	}
+	return fmt.Sprintf("seeked too far %v vs %v", e.Position, e.BitCount)
+}
+
+func (e *SeekError) Is(target error) bool {
+	return target == ErrSeekOutOfRange
+}
+
+// BufferFullError : The target octets can not hold the bits to read
+type BufferFullError struct {
+	OctetCount uint
+	BitCount   uint
+	Tell       uint
+}
+
+func (e *BufferFullError) Error() string {
+	return fmt.Sprintf("target of %v octets is too small for %v bits at position:%v", e.OctetCount, e.BitCount, e.Tell)
+}
+
+func (e *BufferFullError) Is(target error) bool {
+	return target == ErrBufferFull
+}
+
+// InvalidUTF8Error : A string read from the stream is not valid UTF-8. Tell is the position of the length prefix
+type InvalidUTF8Error struct {
+	Tell uint
+}
+
+func (e *InvalidUTF8Error) Error() string {
+	return fmt.Sprintf("string is not valid UTF-8 at position:%v", e.Tell)
+}
+
+func (e *InvalidUTF8Error) Is(target error) bool {
+	return target == ErrInvalidUTF8
+}
+
+// InvalidHeaderError : The debug stream header does not start with the header marker
+type InvalidHeaderError struct {
+	Marker uint32
+	Tell   uint
+}
+
+func (e *InvalidHeaderError) Error() string {
+	return fmt.Sprintf("debug stream header marker %02X is wrong at position:%v", e.Marker, e.Tell)
+}
+
+func (e *InvalidHeaderError) Is(target error) bool {
+	return target == ErrInvalidHeader
+}
+
+// DuplicateKeyError : A map read from the stream has the same key more than once. Tell is the position after the entry
+type DuplicateKeyError struct {
+	Key  interface{}
+	Tell uint
+}
+
+func (e *DuplicateKeyError) Error() string {
+	return fmt.Sprintf("duplicate map key %v at position:%v", e.Key, e.Tell)
+}
+
+func (e *DuplicateKeyError) Is(target error) bool {
+	return target == ErrDuplicateKey
+}
+
+// TypeMismatchError : A debug stream type tag, bit count or label did not match.
+// Path is only set for labeled debug streams.
+type TypeMismatchError struct {
+	Tell             uint
+	Labeled          bool
+	Path             string
+	Expected         debugtag.Tag
+	Received         debugtag.Tag
+	ExpectedBitCount uint
+	ReceivedBitCount uint
+	ExpectedLabel    uint16
+	ReceivedLabel    uint16
+}
+
+func (e *TypeMismatchError) Error() string {
+	var description string
+	if e.Expected != e.Received {
+		description = fmt.Sprintf("Expected %v but received %v (%v vs %v)", debugtag.Name(e.Expected), debugtag.Name(e.Received), uint8(e.Expected), uint8(e.Received))
+	} else if e.ExpectedBitCount != e.ReceivedBitCount {
+		description = fmt.Sprintf("expected %v count but received %v bitcount (type:%v %v)", e.ExpectedBitCount, e.ReceivedBitCount, debugtag.Name(e.Expected), uint8(e.Expected))
+	} else {
+		description = fmt.Sprintf("expected label %04X but received %04X", e.ExpectedLabel, e.ReceivedLabel)
+	}
+	if e.Labeled {
+		return fmt.Sprintf("field '%v' at bit %v: %v", e.Path, e.Tell, description)
+	}
+	return fmt.Sprintf("%v at position:%v", description, e.Tell)
+}
+
+func (e *TypeMismatchError) Is(target error) bool {
+	return target == ErrTypeMismatch
+}
+
+// StickyError : First error in a sticky stream and the bit position where it happened
+type StickyError struct {
+	Tell uint
+	Err  error
+}
+
+func (e *StickyError) Error() string {
+	return fmt.Sprintf("at bit position %v: %v", e.Tell, e.Err)
+}
+
+func (e *StickyError) Unwrap() error {
+	return e.Err
+}
@@ -42,6 +42,10 @@ type InBitStream interface {
 	ReadInt16() (int16, error)
 	// ReadUint8 : Read unsigned 8-bit from stream
 	ReadUint8() (uint8, error)
+	// ReadString : Read string with a lengthBitCount length prefix, at most maxLength octets
+	ReadString(lengthBitCount uint, maxLength uint) (string, error)
+	// ReadBlob : Read octets with a lengthBitCount length prefix, at most maxLen octets
+	ReadBlob(lengthBitCount uint, maxLen uint) ([]byte, error)
 
 	Skip(count uint) error
 	Seek(position uint) error
@@ -27,7 +27,11 @@ SOFTWARE.
 package inbitstream
 
 import (
+	"errors"
+	"io"
 	"testing"
+
+	"github.com/piot/brook-go/src/bits"
 )
 
 func setupWithArray(octets []byte) InBitStream {
@@ -112,3 +116,207 @@ func TestReadTooFar(t *testing.T) {
 		t.Errorf("Expected error")
 	}
 }
+
+func TestReadString(t *testing.T) {
+	octets, bitCount := bits.FromString("00010 0110_1000 0110_1001")
+	bitstream := New(octets, bitCount)
+	s, err := bitstream.ReadString(5, 8)
+	if err != nil {
+		t.Error(err)
+	}
+	if s != "hi" {
+		t.Errorf("Expected hi but got %q", s)
+	}
+}
+
+func TestReadStringInvalid(t *testing.T) {
+	octets, bitCount := bits.FromString("00001 1100_0011")
+	bitstream := New(octets, bitCount)
+	_, err := bitstream.ReadString(5, 8)
+	var invalidUTF8 *InvalidUTF8Error
+	if !errors.As(err, &invalidUTF8) || !errors.Is(err, ErrInvalidUTF8) || invalidUTF8.Tell != 0 {
+		t.Errorf("Expected invalid UTF-8 error at 0, got %v", err)
+	}
+
+	tooLongOctets, tooLongBitCount := bits.FromString("00010 0110_1000 0110_1001")
+	_, tooLongErr := New(tooLongOctets, tooLongBitCount).ReadString(5, 1)
+	if tooLongErr == nil {
+		t.Errorf("Expected error")
+	}
+}
+
+func TestReadBlobGuard(t *testing.T) {
+	bitstream := setup()
+	_, err := bitstream.ReadBlob(32, 0xffffffff)
+	if err == nil {
+		t.Errorf("Expected error")
+	}
+}
+
+func TestErrorTypes(t *testing.T) {
+	bitstream := setup()
+	bitstream.ReadBits(12)
+
+	_, invalidErr := bitstream.ReadBits(33)
+	var invalidBitCount *InvalidBitCountError
+	if !errors.Is(invalidErr, ErrInvalidBitCount) || !errors.As(invalidErr, &invalidBitCount) || invalidBitCount.Tell != 12 {
+		t.Errorf("Expected invalid bit count error at 12, got %v", invalidErr)
+	}
+
+	_, eofErr := bitstream.ReadUint64()
+	var eof *EOFError
+	if !errors.Is(eofErr, io.ErrUnexpectedEOF) || !errors.As(eofErr, &eof) || eof.Tell != 44 {
+		t.Errorf("Expected EOF error at 44, got %v", eofErr)
+	}
+
+	seekErr := bitstream.Seek(65)
+	if !errors.Is(seekErr, ErrSeekOutOfRange) {
+		t.Errorf("Expected seek error, got %v", seekErr)
+	}
+}
+
+func TestHeaderAndMapErrors(t *testing.T) {
+	headerOctets, headerBitCount := bits.FromString("0000_0001 0000_0010 0000_0000")
+	_, headerErr := NewDebugStreamFromHeader(New(headerOctets, headerBitCount))
+	var invalidHeader *InvalidHeaderError
+	if !errors.Is(headerErr, ErrInvalidHeader) || !errors.As(headerErr, &invalidHeader) || invalidHeader.Marker != 1 {
+		t.Errorf("Expected invalid header error, got %v", headerErr)
+	}
+
+	readNibble := func(in InBitStream) (uint32, error) { return in.ReadBits(4) }
+	mapOctets, mapBitCount := bits.FromString("0010 0011 0001 0011 0010")
+	_, mapErr := ReadMap(New(mapOctets, mapBitCount), 4, 2, readNibble, readNibble)
+	var duplicateKey *DuplicateKeyError
+	if !errors.Is(mapErr, ErrDuplicateKey) || !errors.As(mapErr, &duplicateKey) || duplicateKey.Tell != 20 {
+		t.Errorf("Expected duplicate key error at 20, got %v", mapErr)
+	}
+}
+
+func TestSeek(t *testing.T) {
+	bitstream := setup()
+	bitstream.ReadBits(30)
+	seekErr := bitstream.Seek(0)
+	if seekErr != nil {
+		t.Fatal(seekErr)
+	}
+	v, err := bitstream.ReadBits(12)
+	if err != nil {
+		t.Error(err)
+	}
+	if v != 0xcaf {
+		t.Errorf("Expected CAF but got %X", v)
+	}
+	if !errors.Is(bitstream.Seek(36), ErrSeekOutOfRange) {
+		t.Errorf("Expected seek error for position other than zero")
+	}
+}
+
+func TestSkip(t *testing.T) {
+	bitstream := setup()
+	bitstream.ReadBits(30)
+	skipErr := bitstream.Skip(6)
+	if skipErr != nil {
+		t.Fatal(skipErr)
+	}
+	v, err := bitstream.ReadBits(12)
+	if err != nil {
+		t.Error(err)
+	}
+	if v != 0x0de {
+		t.Errorf("Expected 0DE but got %X", v)
+	}
+	if !errors.Is(bitstream.Skip(17), io.ErrUnexpectedEOF) {
+		t.Errorf("Expected EOF error for skipping past the end")
+	}
+}
+
+func TestCopyToOctets(t *testing.T) {
+	bitstream := setup()
+	bitstream.ReadBits(4)
+	target := make([]byte, 6)
+	err := CopyToOctets(bitstream, target, 43)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []byte{0xaf, 0xed, 0xea, 0xdc, 0x0d, 0xe0}
+	for i := range expected {
+		if target[i] != expected[i] {
+			t.Errorf("Octet %v: expected %02x but got %02x", i, expected[i], target[i])
+		}
+	}
+	copyErr := CopyToOctets(bitstream, make([]byte, 1), 9)
+	var bufferFull *BufferFullError
+	if !errors.As(copyErr, &bufferFull) || bufferFull.Tell != 47 {
+		t.Errorf("Expected BufferFullError at bit 47 for too small target but got %v", copyErr)
+	}
+}
+
+func TestCopyToOctetsMatchesOneWordAtATime(t *testing.T) {
+	octets := make([]byte, 40)
+	for i := range octets {
+		octets[i] = byte(i*37 + 11)
+	}
+	for offset := uint(0); offset < 8; offset++ {
+		for _, bitCount := range []uint{0, 5, 8, 43, 64, 250} {
+			bulk := New(octets, uint(len(octets))*8)
+			bulk.Skip(offset)
+			bulkTarget := make([]byte, (bitCount+7)/8)
+			if err := CopyToOctets(bulk, bulkTarget, bitCount); err != nil {
+				t.Fatal(err)
+			}
+			wrapped := NewSticky(New(octets, uint(len(octets))*8))
+			wrapped.Skip(offset)
+			wordTarget := make([]byte, (bitCount+7)/8)
+			if err := CopyToOctets(wrapped, wordTarget, bitCount); err != nil {
+				t.Fatal(err)
+			}
+			if bits.ToString(bulkTarget) != bits.ToString(wordTarget) {
+				t.Errorf("offset %v, %v bits: bulk %v differs from %v", offset, bitCount, bits.ToString(bulkTarget), bits.ToString(wordTarget))
+			}
+			next, _ := bulk.ReadBits(5)
+			wrappedNext, _ := wrapped.ReadBits(5)
+			if next != wrappedNext {
+				t.Errorf("offset %v, %v bits: stream left at different positions", offset, bitCount)
+			}
+		}
+	}
+}
+
+func TestReadBlock(t *testing.T) {
+	bitstream := New([]byte{0xca, 0xfe, 0xde, 0xad, 0xc0, 0xde, 0xff, 0x00}, 8*8)
+	bitstream.ReadBits(12)
+	octets, bitOffset, err := bitstream.ReadBlock(20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(octets) != 3 || octets[0] != 0xfe || bitOffset != 4 {
+		t.Errorf("Expected three octets from 0xfe at bit offset 4 but got %x at %v", octets, bitOffset)
+	}
+	next, _ := bitstream.ReadBits(8)
+	if next != 0xc0 {
+		t.Errorf("Expected c0 after the block but got %02x", next)
+	}
+	_, _, eofErr := bitstream.ReadBlock(64)
+	if !errors.Is(eofErr, io.ErrUnexpectedEOF) {
+		t.Errorf("Expected EOF but got %v", eofErr)
+	}
+}
+
+func TestPeekBits(t *testing.T) {
+	bitstream := New([]byte{0xca, 0xfe, 0xde, 0xad, 0xc0, 0xde, 0xff, 0xff}, 7*8)
+	bitstream.ReadBits(28)
+	peeked, err := bitstream.PeekBits(12)
+	if err != nil || peeked != 0xdc0 {
+		t.Errorf("Expected dc0 across the word boundary but got %03x (%v)", peeked, err)
+	}
+	next, _ := bitstream.ReadBits(12)
+	if next != peeked {
+		t.Errorf("Expected peek to not move the position, read %03x", next)
+	}
+	bitstream.ReadBits(12)
+	// Only four bits are left, the bits after the end are returned as zero
+	last, _ := bitstream.PeekBits(8)
+	if last != 0xf0 {
+		t.Errorf("Expected f0 but got %02x", last)
+	}
+}
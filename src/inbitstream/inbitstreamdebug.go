@@ -27,64 +27,118 @@ SOFTWARE.
 // Package inbitstream ...
 package inbitstream
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/piot/brook-go/src/debugtag"
+)
 
 type InBitStreamDebug struct {
-	stream InBitStream
+	stream      InBitStream
+	labeled     bool
+	labels      DebugLabelPath
+	tagBitCount uint
+}
+
+func NewDebugStream(stream InBitStream) *InBitStreamDebug {
+	return &InBitStreamDebug{stream: stream, tagBitCount: 4}
 }
 
-func debugTypeValueToString(expectedType int) string {
-	switch expectedType {
-	case 1:
-		return "uint16"
-	case 2:
-		return "int16"
-	case 3:
-		return "uint32"
-	case 4:
-		return "uint64"
-	case 5:
-		return "uint8"
-	case 6:
-		return "signed"
-	case 7:
-		return "unsigned"
+// NewLabeledDebugStream : Debug stream that also verifies the field label for each value
+func NewLabeledDebugStream(stream InBitStream) *InBitStreamDebug {
+	return &InBitStreamDebug{stream: stream, labeled: true, tagBitCount: 4}
+}
+
+// NewDebugStreamFromHeader : Reads the debug format header and sets up the version and labeling from it
+func NewDebugStreamFromHeader(stream InBitStream) (*InBitStreamDebug, error) {
+	start := TellOf(stream)
+	marker, markerErr := stream.ReadBits(8)
+	if markerErr != nil {
+		return nil, markerErr
+	}
+	if marker != debugtag.HeaderMarker {
+		return nil, &InvalidHeaderError{Marker: marker, Tell: start}
+	}
+	version, versionErr := stream.ReadBits(8)
+	if versionErr != nil {
+		return nil, versionErr
 	}
+	tagBitCount, tagBitCountErr := debugtag.TagBitCount(debugtag.FormatVersion(version))
+	if tagBitCountErr != nil {
+		return nil, tagBitCountErr
+	}
+	flags, flagsErr := stream.ReadBits(8)
+	if flagsErr != nil {
+		return nil, flagsErr
+	}
+	labeled := flags&debugtag.FlagLabeled != 0
+	return &InBitStreamDebug{stream: stream, labeled: labeled, tagBitCount: tagBitCount}, nil
+}
 
-	return "unknown"
+// Label : Sets the expected field name for the next read
+func (i *InBitStreamDebug) Label(name string) {
+	i.labels.SetLeaf(name)
 }
 
-func NewDebugStream(stream InBitStream) *InBitStreamDebug {
-	return &InBitStreamDebug{stream: stream}
+// PushLabel : Enters a scope in the field path, e.g. a nested struct
+func (i *InBitStreamDebug) PushLabel(name string) {
+	i.labels.Push(name)
+}
+
+// PopLabel : Leaves the latest scope in the field path
+func (i *InBitStreamDebug) PopLabel() {
+	i.labels.Pop()
 }
 
 func (i *InBitStreamDebug) Tell() uint {
-	tryInfo, tryInfoWorked := i.stream.(InBitStreamInfo)
-	if !tryInfoWorked {
-		return 0
-	}
-	return tryInfo.Tell()
+	return TellOf(i.stream)
 }
 
 func (i *InBitStreamDebug) IsEOF() bool {
 	return i.stream.IsEOF()
 }
 
-func (i *InBitStreamDebug) checkType(expectedType int, expectedBitCount uint) error {
-	t, tErr := i.internalRead(4)
+// CheckTypeTag : Reads a marker for a user defined type registered in debugtag
+func (i *InBitStreamDebug) CheckTypeTag(expectedType debugtag.Tag) error {
+	return i.checkType(expectedType, 0)
+}
+
+func (i *InBitStreamDebug) checkType(expectedType debugtag.Tag, expectedBitCount uint) error {
+	position := i.Tell()
+	path := ""
+	if i.labeled {
+		path = i.labels.Take()
+	}
+
+	t, tErr := i.internalRead(i.tagBitCount)
 	if tErr != nil {
 		return tErr
 	}
-	if int(t) != expectedType {
-		return fmt.Errorf("Expected %v but received %v (%v vs %v)", debugTypeValueToString(expectedType), debugTypeValueToString(int(t)), expectedType, t)
+	mismatch := &TypeMismatchError{Tell: position, Labeled: i.labeled, Path: path,
+		Expected: expectedType, Received: debugtag.Tag(t), ExpectedBitCount: expectedBitCount}
+	if mismatch.Received != expectedType {
+		return mismatch
 	}
 
-	bitCount, bitCountErr := i.internalRead(7)
+	bitCount, bitCountErr := i.internalRead(debugtag.CountBitCount)
 	if bitCountErr != nil {
 		return bitCountErr
 	}
-	if uint(bitCount) != expectedBitCount {
-		return fmt.Errorf("expected %v count but received %v bitcount (type:%v %v)", expectedBitCount, bitCount, debugTypeValueToString(expectedType), expectedType)
+	mismatch.ReceivedBitCount = uint(bitCount)
+	if mismatch.ReceivedBitCount != expectedBitCount {
+		return mismatch
+	}
+
+	if i.labeled {
+		label, labelErr := i.internalRead(DebugLabelBitCount)
+		if labelErr != nil {
+			return labelErr
+		}
+		mismatch.ExpectedLabel = DebugLabelHash(path)
+		mismatch.ReceivedLabel = uint16(label)
+		if mismatch.ReceivedLabel != mismatch.ExpectedLabel {
+			return mismatch
+		}
 	}
 
 	return nil
@@ -99,7 +153,7 @@ func (i *InBitStreamDebug) ReadRawBits(count uint) (uint32, error) {
 }
 
 func (i *InBitStreamDebug) ReadBits(count uint) (uint32, error) {
-	checkErr := i.checkType(7, count)
+	checkErr := i.checkType(debugtag.Unsigned, count)
 	if checkErr != nil {
 		return 0, checkErr
 	}
@@ -120,7 +174,7 @@ func (i *InBitStreamDebug) Octets() []byte {
 
 // ReadSignedBits : Read signed bits from stream
 func (i *InBitStreamDebug) ReadSignedBits(count uint) (int32, error) {
-	checkErr := i.checkType(6, count)
+	checkErr := i.checkType(debugtag.Signed, count)
 	if checkErr != nil {
 		return 0, checkErr
 	}
@@ -129,7 +183,7 @@ func (i *InBitStreamDebug) ReadSignedBits(count uint) (int32, error) {
 
 // ReadUint64 : Read unsigned 64-bit from stream
 func (i *InBitStreamDebug) ReadUint64() (uint64, error) {
-	checkErr := i.checkType(4, 64)
+	checkErr := i.checkType(debugtag.Uint64, 64)
 	if checkErr != nil {
 		return 0, checkErr
 	}
@@ -138,7 +192,7 @@ func (i *InBitStreamDebug) ReadUint64() (uint64, error) {
 
 // ReadUint32 : Read unsigned 32-bit from stream
 func (i *InBitStreamDebug) ReadUint32() (uint32, error) {
-	checkErr := i.checkType(3, 32)
+	checkErr := i.checkType(debugtag.Uint32, 32)
 	if checkErr != nil {
 		return 0, checkErr
 	}
@@ -147,7 +201,7 @@ func (i *InBitStreamDebug) ReadUint32() (uint32, error) {
 
 // ReadUint16 : Read unsigned 16-bit from stream
 func (i *InBitStreamDebug) ReadUint16() (uint16, error) {
-	checkErr := i.checkType(1, 16)
+	checkErr := i.checkType(debugtag.Uint16, 16)
 	if checkErr != nil {
 		return 0, checkErr
 	}
@@ -156,7 +210,7 @@ func (i *InBitStreamDebug) ReadUint16() (uint16, error) {
 
 // ReadInt16 : Read unsigned 16-bit from stream
 func (i *InBitStreamDebug) ReadInt16() (int16, error) {
-	checkErr := i.checkType(2, 16)
+	checkErr := i.checkType(debugtag.Int16, 16)
 	if checkErr != nil {
 		return 0, checkErr
 	}
@@ -165,13 +219,31 @@ func (i *InBitStreamDebug) ReadInt16() (int16, error) {
 
 // ReadUint8 : Read unsigned 8-bit from stream
 func (i *InBitStreamDebug) ReadUint8() (uint8, error) {
-	checkErr := i.checkType(5, 8)
+	checkErr := i.checkType(debugtag.Uint8, 8)
 	if checkErr != nil {
 		return 0, checkErr
 	}
 	return i.stream.ReadUint8()
 }
 
+// ReadString : Read string with a lengthBitCount length prefix, at most maxLength octets
+func (i *InBitStreamDebug) ReadString(lengthBitCount uint, maxLength uint) (string, error) {
+	checkErr := i.checkType(debugtag.String, lengthBitCount)
+	if checkErr != nil {
+		return "", checkErr
+	}
+	return i.stream.ReadString(lengthBitCount, maxLength)
+}
+
+// ReadBlob : Read octets with a lengthBitCount length prefix, at most maxLen octets
+func (i *InBitStreamDebug) ReadBlob(lengthBitCount uint, maxLen uint) ([]byte, error) {
+	checkErr := i.checkType(debugtag.Blob, lengthBitCount)
+	if checkErr != nil {
+		return nil, checkErr
+	}
+	return i.stream.ReadBlob(lengthBitCount, maxLen)
+}
+
 func (i *InBitStreamDebug) String() string {
 	return fmt.Sprintf("[bitstreamdebug %v]", i.stream)
-}
\ No newline at end of file
+}
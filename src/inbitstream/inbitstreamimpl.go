@@ -28,7 +28,9 @@ SOFTWARE.
 package inbitstream
 
 import (
+	"encoding/binary"
 	"fmt"
+	"unicode/utf8"
 )
 
 // InBitStreamImpl : Read bit stream
@@ -40,11 +42,12 @@ type InBitStreamImpl struct {
 	position              uint
 	tell                  uint
 	octetReadPosition     int
+	bitCount              uint
 }
 
 // New : Creates an input bit stream
 func New(octets []byte, bitCount uint) *InBitStreamImpl {
-	stream := InBitStreamImpl{octets: octets, data: 0, remainingBits: 0, remainingBitsInStream: bitCount, position: 0}
+	stream := InBitStreamImpl{octets: octets, data: 0, remainingBits: 0, remainingBitsInStream: bitCount, position: 0, bitCount: bitCount}
 	return &stream
 }
 
@@ -60,16 +63,32 @@ func (s *InBitStreamImpl) Octets() []byte {
 	return s.octets
 }
 
+// Seek : Moves the read position back to the start of the stream. Only position zero is supported
 func (s *InBitStreamImpl) Seek(position uint) error {
 	if position != 0 {
-		return fmt.Errorf("Only seek to zero now")
+		return &SeekError{Position: position, BitCount: s.bitCount}
 	}
+	return s.seek(0)
+}
+
+// seek : Moves the read position to any bit position, for ReadBlock and Skip
+func (s *InBitStreamImpl) seek(position uint) error {
+	dwordPosition := position / 32
 	s.remainingBits = 0
-	s.octetReadPosition = 0
+	s.octetReadPosition = int(dwordPosition * 4)
 	s.position = 0
 	s.data = 0
-	s.fill()
-	return nil
+	s.tell = dwordPosition * 32
+	s.remainingBitsInStream = s.bitCount - s.tell
+	fillErr := s.fill()
+	if position%32 == 0 {
+		return nil
+	}
+	if fillErr != nil {
+		return fillErr
+	}
+	_, skipErr := s.readOnce(position % 32)
+	return skipErr
 }
 
 func (s *InBitStreamImpl) IsEOF() bool {
@@ -107,7 +126,7 @@ func (s *InBitStreamImpl) fill() error {
 	newData := uint32(0)
 	remainingOctetCount := len(s.octets) - s.octetReadPosition
 	if remainingOctetCount <= 0 {
-		return &EOFError{}
+		return &EOFError{Tell: s.tell}
 	}
 	octetCountToRead := maxOctetsToRead
 	if octetCountToRead > remainingOctetCount {
@@ -130,22 +149,73 @@ func (s *InBitStreamImpl) ReadRawBits(count uint) (uint32, error) {
 }
 
 func (s *InBitStreamImpl) Skip(count uint) error {
-	dwordCount := count / 32
-	restBitCount := count % 32
-	for i := uint(0); i < dwordCount; i++ {
-		_, dwordErr := s.ReadRawBits(32)
-		if dwordErr != nil {
-			return dwordErr
+	if count <= s.remainingBits && count <= s.remainingBitsInStream {
+		s.remainingBits -= count
+		s.remainingBitsInStream -= count
+		s.tell += count
+		return nil
+	}
+	if count > s.remainingBitsInStream {
+		return &EOFError{Count: count, Tell: s.tell}
+	}
+	return s.seek(s.tell + count)
+}
+
+// PeekBits : Returns the next count bits without moving the read position. Bits after the end
+// of the stream are returned as zero, so a decoder can look ahead a fixed number of bits and
+// then Skip the bits it used
+func (s *InBitStreamImpl) PeekBits(count uint) (uint32, error) {
+	if count > 32 {
+		return 0, &InvalidBitCountError{Count: count, Max: 32, Tell: s.tell}
+	}
+	if count == 0 {
+		return 0, nil
+	}
+	if count <= s.remainingBits && count <= s.remainingBitsInStream {
+		return (s.data >> (s.remainingBits - count)) & maskFromCount(count), nil
+	}
+	octetIndex := int(s.tell / 8)
+	var word uint64
+	if octetIndex+8 <= len(s.octets) {
+		word = binary.BigEndian.Uint64(s.octets[octetIndex:])
+	} else {
+		for i := 0; i < 8; i++ {
+			word <<= 8
+			if octetIndex+i < len(s.octets) {
+				word |= uint64(s.octets[octetIndex+i])
+			}
 		}
 	}
-	_, err := s.ReadRawBits(restBitCount)
-	return err
+	v := uint32(word << (s.tell % 8) >> (64 - count))
+	if count > s.remainingBitsInStream {
+		v &^= maskFromCount(count - s.remainingBitsInStream)
+	}
+	return v, nil
+}
+
+// ReadBlock : Skips bitCount bits and returns the octets they are stored in, starting with the octet
+// holding the first bit, together with the bit offset of the first bit in that octet.
+// Used to copy large blocks of bits without reading them one word at a time
+func (s *InBitStreamImpl) ReadBlock(bitCount uint) ([]byte, uint, error) {
+	if bitCount > s.remainingBitsInStream {
+		return nil, 0, &EOFError{Count: bitCount, Tell: s.tell}
+	}
+	start := s.tell
+	end := start + bitCount
+	if (end+7)/8 > uint(len(s.octets)) {
+		return nil, 0, &EOFError{Count: bitCount, Tell: s.tell}
+	}
+	seekErr := s.seek(end)
+	if seekErr != nil {
+		return nil, 0, seekErr
+	}
+	return s.octets[start/8 : (end+7)/8], start % 8, nil
 }
 
 // ReadBits : Read bits from stream
 func (s *InBitStreamImpl) ReadBits(count uint) (uint32, error) {
 	if count > 32 {
-		return 0, fmt.Errorf("Max 32 bits to read")
+		return 0, &InvalidBitCountError{Count: count, Max: 32, Tell: s.tell}
 	}
 
 	if count > s.remainingBitsInStream {
@@ -230,6 +300,42 @@ func (s *InBitStreamImpl) ReadUint8() (uint8, error) {
 	return uint8(v), err
 }
 
+// ReadString : Read string with a lengthBitCount length prefix, at most maxLength octets
+func (s *InBitStreamImpl) ReadString(lengthBitCount uint, maxLength uint) (string, error) {
+	start := s.tell
+	octets, readErr := s.ReadBlob(lengthBitCount, maxLength)
+	if readErr != nil {
+		return "", readErr
+	}
+	if !utf8.Valid(octets) {
+		return "", &InvalidUTF8Error{Tell: start}
+	}
+	return string(octets), nil
+}
+
+// ReadBlob : Read octets with a lengthBitCount length prefix, at most maxLen octets
+func (s *InBitStreamImpl) ReadBlob(lengthBitCount uint, maxLen uint) ([]byte, error) {
+	length, lengthErr := s.ReadBits(lengthBitCount)
+	if lengthErr != nil {
+		return nil, lengthErr
+	}
+	if uint(length) > maxLen {
+		return nil, &OverflowError{Value: uint64(length), Max: uint64(maxLen), Tell: s.tell}
+	}
+	if uint64(length)*8 > uint64(s.remainingBitsInStream) {
+		return nil, &EOFError{Count: uint(length) * 8, Tell: s.tell}
+	}
+	octets := make([]byte, length)
+	for i := range octets {
+		octet, octetErr := s.ReadBits(8)
+		if octetErr != nil {
+			return nil, octetErr
+		}
+		octets[i] = byte(octet)
+	}
+	return octets, nil
+}
+
 func (s *InBitStreamImpl) String() string {
 	return fmt.Sprintf("[inbitstream pos:%v remainingbits %v]", s.tell, s.remainingBitsInStream)
 }
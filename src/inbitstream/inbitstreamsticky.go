@@ -0,0 +1,130 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package inbitstream ...
+package inbitstream
+
+import "fmt"
+
+// InBitStreamSticky : Remembers the first error, after which all reads return zero values.
+// Check Err() once after all reads are done.
+type InBitStreamSticky struct {
+	stream InBitStream
+	err    error
+}
+
+// NewSticky : Creates a sticky error wrapper around an input bit stream
+func NewSticky(stream InBitStream) *InBitStreamSticky {
+	return &InBitStreamSticky{stream: stream}
+}
+
+// Err : The first error as a *StickyError, or nil
+func (i *InBitStreamSticky) Err() error {
+	return i.err
+}
+
+func stickyRead[T any](i *InBitStreamSticky, readFunc func() (T, error)) (T, error) {
+	var zero T
+	if i.err != nil {
+		return zero, i.err
+	}
+	tell := TellOf(i.stream)
+	v, err := readFunc()
+	if err != nil {
+		i.err = &StickyError{Tell: tell, Err: err}
+		return zero, i.err
+	}
+	return v, nil
+}
+
+func (i *InBitStreamSticky) do(operation func() error) error {
+	_, err := stickyRead(i, func() (struct{}, error) { return struct{}{}, operation() })
+	return err
+}
+
+func (i *InBitStreamSticky) Tell() uint {
+	return TellOf(i.stream)
+}
+
+func (i *InBitStreamSticky) IsEOF() bool {
+	return i.stream.IsEOF()
+}
+
+func (i *InBitStreamSticky) Octets() []byte {
+	return i.stream.Octets()
+}
+
+func (i *InBitStreamSticky) Seek(position uint) error {
+	return i.do(func() error { return i.stream.Seek(position) })
+}
+
+func (i *InBitStreamSticky) Skip(count uint) error {
+	return i.do(func() error { return i.stream.Skip(count) })
+}
+
+func (i *InBitStreamSticky) ReadBits(count uint) (uint32, error) {
+	return stickyRead(i, func() (uint32, error) { return i.stream.ReadBits(count) })
+}
+
+func (i *InBitStreamSticky) ReadRawBits(count uint) (uint32, error) {
+	return stickyRead(i, func() (uint32, error) { return i.stream.ReadRawBits(count) })
+}
+
+func (i *InBitStreamSticky) ReadSignedBits(count uint) (int32, error) {
+	return stickyRead(i, func() (int32, error) { return i.stream.ReadSignedBits(count) })
+}
+
+func (i *InBitStreamSticky) ReadUint64() (uint64, error) {
+	return stickyRead(i, i.stream.ReadUint64)
+}
+
+func (i *InBitStreamSticky) ReadUint32() (uint32, error) {
+	return stickyRead(i, i.stream.ReadUint32)
+}
+
+func (i *InBitStreamSticky) ReadUint16() (uint16, error) {
+	return stickyRead(i, i.stream.ReadUint16)
+}
+
+func (i *InBitStreamSticky) ReadInt16() (int16, error) {
+	return stickyRead(i, i.stream.ReadInt16)
+}
+
+func (i *InBitStreamSticky) ReadUint8() (uint8, error) {
+	return stickyRead(i, i.stream.ReadUint8)
+}
+
+func (i *InBitStreamSticky) ReadString(lengthBitCount uint, maxLength uint) (string, error) {
+	return stickyRead(i, func() (string, error) { return i.stream.ReadString(lengthBitCount, maxLength) })
+}
+
+func (i *InBitStreamSticky) ReadBlob(lengthBitCount uint, maxLen uint) ([]byte, error) {
+	return stickyRead(i, func() ([]byte, error) { return i.stream.ReadBlob(lengthBitCount, maxLen) })
+}
+
+func (i *InBitStreamSticky) String() string {
+	return fmt.Sprintf("[bitstreamsticky %v err:%v]", i.stream, i.err)
+}
@@ -30,3 +30,12 @@ package inbitstream
 type InBitStreamInfo interface {
 	Tell() uint
 }
+
+// TellOf : Bit position of the stream, or zero if the stream does not report it
+func TellOf(stream InBitStream) uint {
+	info, hasInfo := stream.(InBitStreamInfo)
+	if !hasInfo {
+		return 0
+	}
+	return info.Tell()
+}
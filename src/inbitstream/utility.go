@@ -27,7 +27,65 @@ SOFTWARE.
 // Package inbitstream ...
 package inbitstream
 
+import (
+	"encoding/binary"
+
+	"github.com/piot/brook-go/src/bits"
+)
+
 func ReadBool(sourceStream InBitStream) (bool, error) {
 	readValue, err := sourceStream.ReadBits(1)
 	return readValue != 0, err
 }
+
+// CopyToOctets : Reads bitCount bits into target, using the same MSB-first layout as the bit streams.
+// Bits in the last octet that are not read are cleared. Like CopyFromOctets, the bits have no debug tags
+func CopyToOctets(sourceStream InBitStream, target []byte, bitCount uint) error {
+	octetCount := (bitCount + 7) / 8
+	if uint(len(target)) < octetCount {
+		return &BufferFullError{OctetCount: uint(len(target)), BitCount: bitCount, Tell: TellOf(sourceStream)}
+	}
+	impl, isImpl := sourceStream.(*InBitStreamImpl)
+	if !isImpl {
+		return copyToOctetsOneWordAtATime(sourceStream, target, bitCount)
+	}
+	source, bitOffset, readErr := impl.ReadBlock(bitCount)
+	if readErr != nil {
+		return readErr
+	}
+	bits.CopyShifted(target[:octetCount], source, bitOffset)
+	lastBitCount := bitCount % 8
+	if lastBitCount != 0 {
+		target[octetCount-1] &= byte(0xff << (8 - lastBitCount))
+	}
+	return nil
+}
+
+// copyToOctetsOneWordAtATime : Uses raw reads, since CopyFromOctets writes the bits without debug tags
+func copyToOctetsOneWordAtATime(sourceStream InBitStream, target []byte, bitCount uint) error {
+	octetCount := (bitCount + 7) / 8
+	position := uint(0)
+	for ; position+4 <= bitCount/8; position += 4 {
+		word, readErr := sourceStream.ReadRawBits(32)
+		if readErr != nil {
+			return readErr
+		}
+		binary.BigEndian.PutUint32(target[position:position+4], word)
+	}
+	for ; position < bitCount/8; position++ {
+		octet, readErr := sourceStream.ReadRawBits(8)
+		if readErr != nil {
+			return readErr
+		}
+		target[position] = byte(octet)
+	}
+	lastBitCount := bitCount % 8
+	if lastBitCount != 0 {
+		last, lastErr := sourceStream.ReadRawBits(lastBitCount)
+		if lastErr != nil {
+			return lastErr
+		}
+		target[octetCount-1] = byte(last << (8 - lastBitCount))
+	}
+	return nil
+}
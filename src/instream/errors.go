@@ -0,0 +1,112 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package instream ...
+package instream
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	// ErrOverflow : A length read from the stream is larger than allowed
+	ErrOverflow = errors.New("overflow")
+	// ErrInvalidLengthPrefix : The length prefix octet count is not supported
+	ErrInvalidLengthPrefix = errors.New("invalid length prefix")
+	// ErrInvalidUTF8 : A string read from the stream is not valid UTF-8
+	ErrInvalidUTF8 = errors.New("invalid UTF-8")
+)
+
+// EOFError : Tried to read past the end of the stream. Matches io.ErrUnexpectedEOF using errors.Is
+type EOFError struct {
+	Count int
+	Tell  int
+}
+
+func (e *EOFError) Error() string {
+	return fmt.Sprintf("Couldn't read all octets, tried to read %v at position:%v", e.Count, e.Tell)
+}
+
+func (e *EOFError) Is(target error) bool {
+	return target == io.ErrUnexpectedEOF
+}
+
+// OverflowError : A length read from the stream is larger than allowed
+type OverflowError struct {
+	Value int
+	Max   int
+	Tell  int
+}
+
+func (e *OverflowError) Error() string {
+	return fmt.Sprintf("length %v exceeds max length %v at position:%v", e.Value, e.Max, e.Tell)
+}
+
+func (e *OverflowError) Is(target error) bool {
+	return target == ErrOverflow
+}
+
+// InvalidLengthPrefixError : The length prefix octet count is not supported
+type InvalidLengthPrefixError struct {
+	OctetCount int
+}
+
+func (e *InvalidLengthPrefixError) Error() string {
+	return fmt.Sprintf("length prefix must be 1, 2 or 4 octets, not %v", e.OctetCount)
+}
+
+func (e *InvalidLengthPrefixError) Is(target error) bool {
+	return target == ErrInvalidLengthPrefix
+}
+
+// InvalidUTF8Error : A string read from the stream is not valid UTF-8. Tell is the position of the length prefix
+type InvalidUTF8Error struct {
+	Tell int
+}
+
+func (e *InvalidUTF8Error) Error() string {
+	return fmt.Sprintf("string is not valid UTF-8 at position:%v", e.Tell)
+}
+
+func (e *InvalidUTF8Error) Is(target error) bool {
+	return target == ErrInvalidUTF8
+}
+
+// StickyError : First error in a sticky stream and the octet position where it happened
+type StickyError struct {
+	Tell int
+	Err  error
+}
+
+func (e *StickyError) Error() string {
+	return fmt.Sprintf("at octet position %v: %v", e.Tell, e.Err)
+}
+
+func (e *StickyError) Unwrap() error {
+	return e.Err
+}
@@ -30,8 +30,8 @@ package instream
 import (
 	"bytes"
 	"encoding/binary"
-	"errors"
 	"fmt"
+	"unicode/utf8"
 )
 
 // InStream : Read octet stream
@@ -63,6 +63,9 @@ func (stream *InStream) IsEOF() bool {
 
 // Read : Reads octets from the stream
 func (stream *InStream) Read(octetCount int) ([]byte, error) {
+	if octetCount < 0 || octetCount > stream.buffer.Len() {
+		return nil, &EOFError{Count: octetCount, Tell: stream.position}
+	}
 	tempBuffer := make([]byte, octetCount)
 	lengthWritten, err := stream.buffer.Read(tempBuffer)
 	if err != nil {
@@ -70,8 +73,7 @@ func (stream *InStream) Read(octetCount int) ([]byte, error) {
 	}
 
 	if lengthWritten != octetCount {
-		err := errors.New("Couldn't read all octets")
-		return nil, err
+		return nil, &EOFError{Count: octetCount, Tell: stream.position}
 	}
 	stream.position += octetCount
 	return tempBuffer, nil
@@ -121,6 +123,46 @@ func (stream *InStream) ReadUint8() (uint8, error) {
 	return v[0], nil
 }
 
+func (stream *InStream) readLength(lengthOctetCount int) (int, error) {
+	switch lengthOctetCount {
+	case 1:
+		v, err := stream.ReadUint8()
+		return int(v), err
+	case 2:
+		v, err := stream.ReadUint16()
+		return int(v), err
+	case 4:
+		v, err := stream.ReadUint32()
+		return int(v), err
+	}
+	return 0, &InvalidLengthPrefixError{OctetCount: lengthOctetCount}
+}
+
+// ReadString : Reads a string prefixed with a lengthOctetCount (1, 2 or 4) octet length. Fails if longer than maxLength octets or not valid UTF-8
+func (stream *InStream) ReadString(lengthOctetCount int, maxLength int) (string, error) {
+	start := stream.position
+	octets, readErr := stream.ReadBlob(lengthOctetCount, maxLength)
+	if readErr != nil {
+		return "", readErr
+	}
+	if !utf8.Valid(octets) {
+		return "", &InvalidUTF8Error{Tell: start}
+	}
+	return string(octets), nil
+}
+
+// ReadBlob : Reads octets prefixed with a lengthOctetCount (1, 2 or 4) octet length. Fails before allocating if longer than maxLen octets
+func (stream *InStream) ReadBlob(lengthOctetCount int, maxLen int) ([]byte, error) {
+	length, lengthErr := stream.readLength(lengthOctetCount)
+	if lengthErr != nil {
+		return nil, lengthErr
+	}
+	if length > maxLen {
+		return nil, &OverflowError{Value: length, Max: maxLen, Tell: stream.position}
+	}
+	return stream.Read(length)
+}
+
 func (stream *InStream) String() string {
 	return fmt.Sprintf("[instream buffer size:%d]", stream.buffer.Len())
 }
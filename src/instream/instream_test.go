@@ -27,6 +27,8 @@ SOFTWARE.
 package instream
 
 import (
+	"errors"
+	"io"
 	"testing"
 )
 
@@ -55,3 +57,86 @@ func Test16and32bit(t *testing.T) {
 		t.Errorf("Should have failed...")
 	}
 }
+
+func TestReadString(t *testing.T) {
+	octets := []byte{0x05, 'b', 'r', 'o', 'o', 'k', 0x02, 0xc3, 0x28}
+	stream := New(octets)
+
+	s, err := stream.ReadString(1, 16)
+	if err != nil {
+		t.Error(err)
+	}
+	if s != "brook" {
+		t.Errorf("Wrong string:%v", s)
+	}
+
+	_, invalidErr := stream.ReadString(1, 16)
+	if !errors.Is(invalidErr, ErrInvalidUTF8) {
+		t.Errorf("Should have failed on invalid UTF-8, got %v", invalidErr)
+	}
+}
+
+func TestReadStringTooLong(t *testing.T) {
+	octets := []byte{0x05, 'b', 'r', 'o', 'o', 'k'}
+	stream := New(octets)
+
+	_, err := stream.ReadString(1, 4)
+	if err == nil {
+		t.Errorf("Should have failed...")
+	}
+}
+
+func TestReadBlobGuard(t *testing.T) {
+	octets := []byte{0xff, 0xff, 0xff, 0xf0, 0x01, 0x02}
+	_, tooLongErr := New(octets).ReadBlob(4, 1024)
+	if tooLongErr == nil {
+		t.Errorf("Should have failed on max length")
+	}
+
+	_, truncatedErr := New(octets).ReadBlob(4, 0xffffffff)
+	if truncatedErr == nil {
+		t.Errorf("Should have failed on truncated blob")
+	}
+
+	blob, err := New([]byte{0x02, 0xca, 0xfe}).ReadBlob(1, 2)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(blob) != 2 || blob[0] != 0xca || blob[1] != 0xfe {
+		t.Errorf("Wrong blob:%v", blob)
+	}
+}
+
+func TestErrorTypes(t *testing.T) {
+	stream := New([]byte{0xca, 0xfe, 0x03, 0x01})
+	stream.ReadUint16()
+	_, overflowErr := stream.ReadBlob(1, 2)
+	var overflow *OverflowError
+	if !errors.Is(overflowErr, ErrOverflow) || !errors.As(overflowErr, &overflow) || overflow.Tell != 3 {
+		t.Errorf("Expected overflow error, got %v", overflowErr)
+	}
+
+	_, eofErr := stream.ReadUint16()
+	if !errors.Is(eofErr, io.ErrUnexpectedEOF) {
+		t.Errorf("Expected EOF error, got %v", eofErr)
+	}
+
+	_, prefixErr := stream.ReadBlob(3, 2)
+	if !errors.Is(prefixErr, ErrInvalidLengthPrefix) {
+		t.Errorf("Expected invalid length prefix error, got %v", prefixErr)
+	}
+}
+
+func TestSticky(t *testing.T) {
+	stream := NewSticky(New([]byte{0xca, 0xfe, 0xde}))
+	stream.ReadUint16()
+	stream.ReadUint16()
+	v, _ := stream.ReadUint8()
+	if v != 0 {
+		t.Errorf("Expected zero value after error")
+	}
+	var sticky *StickyError
+	if !errors.As(stream.Err(), &sticky) || sticky.Tell != 2 || !errors.Is(stream.Err(), io.ErrUnexpectedEOF) {
+		t.Errorf("Expected sticky EOF error at 2, got %v", stream.Err())
+	}
+}
@@ -0,0 +1,108 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package instream ...
+package instream
+
+// InStreamSticky : Remembers the first error, after which all reads return zero values.
+// Check Err() once after all reads are done.
+type InStreamSticky struct {
+	stream *InStream
+	err    error
+}
+
+// NewSticky : Creates a sticky error wrapper around an input stream
+func NewSticky(stream *InStream) *InStreamSticky {
+	return &InStreamSticky{stream: stream}
+}
+
+// Err : The first error as a *StickyError, or nil
+func (s *InStreamSticky) Err() error {
+	return s.err
+}
+
+func stickyRead[T any](s *InStreamSticky, readFunc func() (T, error)) (T, error) {
+	var zero T
+	if s.err != nil {
+		return zero, s.err
+	}
+	tell := s.stream.Tell()
+	v, err := readFunc()
+	if err != nil {
+		s.err = &StickyError{Tell: tell, Err: err}
+		return zero, s.err
+	}
+	return v, nil
+}
+
+func (s *InStreamSticky) Tell() int {
+	return s.stream.Tell()
+}
+
+// IsEOF : Checks if the input stream is empty
+func (s *InStreamSticky) IsEOF() bool {
+	return s.stream.IsEOF()
+}
+
+// Read : Reads octets from the stream
+func (s *InStreamSticky) Read(octetCount int) ([]byte, error) {
+	return stickyRead(s, func() ([]byte, error) { return s.stream.Read(octetCount) })
+}
+
+// ReadOctets : Reads octets from the stream
+func (s *InStreamSticky) ReadOctets(octetCount int) ([]byte, error) {
+	return s.Read(octetCount)
+}
+
+// ReadUint64 reads an unsigned 64-bit integer from the stream
+func (s *InStreamSticky) ReadUint64() (uint64, error) {
+	return stickyRead(s, s.stream.ReadUint64)
+}
+
+// ReadUint32 : Reads an unsigned 32-bit integer from the stream
+func (s *InStreamSticky) ReadUint32() (uint32, error) {
+	return stickyRead(s, s.stream.ReadUint32)
+}
+
+// ReadUint16 : Reads an unsigned 16-bit integer from the stream
+func (s *InStreamSticky) ReadUint16() (uint16, error) {
+	return stickyRead(s, s.stream.ReadUint16)
+}
+
+// ReadUint8 : Reads an octet from the stream
+func (s *InStreamSticky) ReadUint8() (uint8, error) {
+	return stickyRead(s, s.stream.ReadUint8)
+}
+
+// ReadString : Reads a length prefixed string from the stream
+func (s *InStreamSticky) ReadString(lengthOctetCount int, maxLength int) (string, error) {
+	return stickyRead(s, func() (string, error) { return s.stream.ReadString(lengthOctetCount, maxLength) })
+}
+
+// ReadBlob : Reads length prefixed octets from the stream
+func (s *InStreamSticky) ReadBlob(lengthOctetCount int, maxLen int) ([]byte, error) {
+	return stickyRead(s, func() ([]byte, error) { return s.stream.ReadBlob(lengthOctetCount, maxLen) })
+}
@@ -0,0 +1,144 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package mixedradix packs several small ranged values into one integer
+package mixedradix
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/piot/brook-go/src/inbitstream"
+	"github.com/piot/brook-go/src/outbitstream"
+)
+
+// Packer : Combines values with non-power-of-two ranges into one mixed radix integer
+type Packer struct {
+	radices  []uint32
+	bitCount uint
+}
+
+// New : Creates a packer. Each radix is the number of possible values for that field,
+// e.g. a value in the range 0..5 has a radix of 6.
+func New(radices []uint32) (*Packer, error) {
+	product := big.NewInt(1)
+	for index, radix := range radices {
+		if radix == 0 {
+			return nil, fmt.Errorf("mixedradix: radix at index %v must be at least 1", index)
+		}
+		product.Mul(product, new(big.Int).SetUint64(uint64(radix)))
+	}
+	maxValue := product.Sub(product, big.NewInt(1))
+	copiedRadices := make([]uint32, len(radices))
+	copy(copiedRadices, radices)
+	return &Packer{radices: copiedRadices, bitCount: uint(maxValue.BitLen())}, nil
+}
+
+// BitCount : Number of bits written for one group of values
+func (p *Packer) BitCount() uint {
+	return p.bitCount
+}
+
+func (p *Packer) pack(values []uint32) (*big.Int, error) {
+	if len(values) != len(p.radices) {
+		return nil, fmt.Errorf("mixedradix: expected %v values but got %v", len(p.radices), len(values))
+	}
+	packed := new(big.Int)
+	radixValue := new(big.Int)
+	for index := len(values) - 1; index >= 0; index-- {
+		radix := p.radices[index]
+		v := values[index]
+		if v >= radix {
+			return nil, fmt.Errorf("mixedradix: value %v at index %v is outside radix %v", v, index, radix)
+		}
+		packed.Mul(packed, radixValue.SetUint64(uint64(radix)))
+		packed.Add(packed, radixValue.SetUint64(uint64(v)))
+	}
+	return packed, nil
+}
+
+func (p *Packer) unpack(packed *big.Int) ([]uint32, error) {
+	values := make([]uint32, len(p.radices))
+	radixValue := new(big.Int)
+	remainder := new(big.Int)
+	for index, radix := range p.radices {
+		packed.QuoRem(packed, radixValue.SetUint64(uint64(radix)), remainder)
+		values[index] = uint32(remainder.Uint64())
+	}
+	if packed.Sign() != 0 {
+		return nil, fmt.Errorf("mixedradix: packed value is outside the radix range")
+	}
+	return values, nil
+}
+
+// Write : Packs the values and writes them using the minimal bit count
+func (p *Packer) Write(targetStream outbitstream.OutBitStream, values []uint32) error {
+	packed, packErr := p.pack(values)
+	if packErr != nil {
+		return packErr
+	}
+
+	word := new(big.Int)
+	mask := big.NewInt(0xffffffff)
+	remaining := p.bitCount
+	for remaining > 0 {
+		count := remaining % 32
+		if count == 0 {
+			count = 32
+		}
+		remaining -= count
+		word.Rsh(packed, remaining)
+		word.And(word, mask)
+		writeErr := targetStream.WriteBits(uint32(word.Uint64()), count)
+		if writeErr != nil {
+			return writeErr
+		}
+	}
+
+	return nil
+}
+
+// Read : Reads a packed group of values and splits them up again
+func (p *Packer) Read(sourceStream inbitstream.InBitStream) ([]uint32, error) {
+	packed := new(big.Int)
+	word := new(big.Int)
+	remaining := p.bitCount
+	for remaining > 0 {
+		count := remaining % 32
+		if count == 0 {
+			count = 32
+		}
+		remaining -= count
+		v, readErr := sourceStream.ReadBits(count)
+		if readErr != nil {
+			return nil, readErr
+		}
+		packed.Lsh(packed, count)
+		packed.Or(packed, word.SetUint64(uint64(v)))
+	}
+
+	return p.unpack(packed)
+}
@@ -0,0 +1,83 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package mixedradix
+
+import (
+	"testing"
+
+	"github.com/piot/brook-go/src/inbitstream"
+	"github.com/piot/brook-go/src/outbitstream"
+)
+
+func TestBitCount(t *testing.T) {
+	packer, err := New([]uint32{6, 3, 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 6 * 3 * 10 = 180 combinations, fits in 8 bits instead of 3 + 2 + 4 = 9
+	if packer.BitCount() != 8 {
+		t.Errorf("Expected 8 bits but got %v", packer.BitCount())
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	radices := []uint32{6, 3, 10, 1000000, 7, 0xffffffff, 5}
+	values := []uint32{5, 0, 9, 999999, 3, 0xfffffffe, 4}
+	packer, err := New(radices)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := outbitstream.New(64)
+	writeErr := packer.Write(out, values)
+	if writeErr != nil {
+		t.Fatal(writeErr)
+	}
+	if out.Tell() != packer.BitCount() {
+		t.Errorf("Expected %v bits written but got %v", packer.BitCount(), out.Tell())
+	}
+
+	in := inbitstream.New(out.Octets(), out.Tell())
+	readValues, readErr := packer.Read(in)
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	for i, v := range values {
+		if readValues[i] != v {
+			t.Errorf("Index %v: expected %v but got %v", i, v, readValues[i])
+		}
+	}
+}
+
+func TestValueOutsideRadix(t *testing.T) {
+	packer, _ := New([]uint32{6, 3})
+	out := outbitstream.New(64)
+	err := packer.Write(out, []uint32{6, 0})
+	if err == nil {
+		t.Errorf("Expected error")
+	}
+}
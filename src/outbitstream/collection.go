@@ -0,0 +1,93 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package outbitstream ...
+package outbitstream
+
+import (
+	"cmp"
+	"slices"
+)
+
+func writeCount(targetStream OutBitStream, count int, countBitCount uint) error {
+	if countBitCount > 32 {
+		return &InvalidBitCountError{Count: countBitCount, Max: 32, Tell: targetStream.Tell()}
+	}
+	if uint64(count) > uint64(maskFromCount(countBitCount)) {
+		return &OverflowError{Value: uint64(count), BitCount: countBitCount, Tell: targetStream.Tell()}
+	}
+	return targetStream.WriteBits(uint32(count), countBitCount)
+}
+
+// WriteSlice : Writes the item count using countBitCount bits, followed by each item
+func WriteSlice[T any](targetStream OutBitStream, items []T, countBitCount uint, writeItem func(OutBitStream, T) error) error {
+	countErr := writeCount(targetStream, len(items), countBitCount)
+	if countErr != nil {
+		return countErr
+	}
+	for _, item := range items {
+		itemErr := writeItem(targetStream, item)
+		if itemErr != nil {
+			return itemErr
+		}
+	}
+	return nil
+}
+
+// WriteMap : Writes the entry count using countBitCount bits, followed by each key and value in ascending key order
+func WriteMap[K cmp.Ordered, V any](targetStream OutBitStream, m map[K]V, countBitCount uint,
+	writeKey func(OutBitStream, K) error, writeValue func(OutBitStream, V) error) error {
+	keys := make([]K, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	countErr := writeCount(targetStream, len(keys), countBitCount)
+	if countErr != nil {
+		return countErr
+	}
+	for _, key := range keys {
+		keyErr := writeKey(targetStream, key)
+		if keyErr != nil {
+			return keyErr
+		}
+		valueErr := writeValue(targetStream, m[key])
+		if valueErr != nil {
+			return valueErr
+		}
+	}
+	return nil
+}
+
+// WriteOptional : Writes a presence bit, followed by the item if v is not nil
+func WriteOptional[T any](targetStream OutBitStream, v *T, writeItem func(OutBitStream, T) error) error {
+	presenceErr := WriteBool(targetStream, v != nil)
+	if presenceErr != nil || v == nil {
+		return presenceErr
+	}
+	return writeItem(targetStream, *v)
+}
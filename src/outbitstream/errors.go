@@ -0,0 +1,122 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package outbitstream ...
+package outbitstream
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrOverflow : A value, length or count does not fit in the bits available
+	ErrOverflow = errors.New("overflow")
+	// ErrInvalidBitCount : Too many bits in one write
+	ErrInvalidBitCount = errors.New("invalid bit count")
+	// ErrSeekOutOfRange : Rewind outside the octet array
+	ErrSeekOutOfRange = errors.New("seek out of range")
+	// ErrBufferFull : No more room in the octet array
+	ErrBufferFull = errors.New("buffer full")
+)
+
+// InvalidBitCountError : Too many bits in one write
+type InvalidBitCountError struct {
+	Count uint
+	Max   uint
+	Tell  uint
+}
+
+func (e *InvalidBitCountError) Error() string {
+	return fmt.Sprintf("Max %v bits to write, tried to write %v at position:%v", e.Max, e.Count, e.Tell)
+}
+
+func (e *InvalidBitCountError) Is(target error) bool {
+	return target == ErrInvalidBitCount
+}
+
+// OverflowError : A value, length or count does not fit in the bits available, or is larger than Max when that is set
+type OverflowError struct {
+	Value    uint64
+	BitCount uint
+	Max      uint64
+	Tell     uint
+}
+
+func (e *OverflowError) Error() string {
+	if e.Max != 0 {
+		return fmt.Sprintf("%v exceeds max %v at position:%v", e.Value, e.Max, e.Tell)
+	}
+	return fmt.Sprintf("%v does not fit in %v bits at position:%v", e.Value, e.BitCount, e.Tell)
+}
+
+func (e *OverflowError) Is(target error) bool {
+	return target == ErrOverflow
+}
+
+// SeekError : Rewind outside the octet array
+type SeekError struct {
+	Position uint
+	BitCount uint
+}
+
+func (e *SeekError) Error() string {
+	return fmt.Sprintf("seeked too far %v vs %v", e.Position, e.BitCount)
+}
+
+func (e *SeekError) Is(target error) bool {
+	return target == ErrSeekOutOfRange
+}
+
+// BufferFullError : No more room in the octet array
+type BufferFullError struct {
+	OctetPosition uint
+	OctetCount    uint
+	Tell          uint
+}
+
+func (e *BufferFullError) Error() string {
+	return fmt.Sprintf("write accumulator: octet positions outside octet array (%v out of %v) at position:%v",
+		e.OctetPosition, e.OctetCount, e.Tell)
+}
+
+func (e *BufferFullError) Is(target error) bool {
+	return target == ErrBufferFull
+}
+
+// StickyError : First error in a sticky stream and the bit position where it happened
+type StickyError struct {
+	Tell uint
+	Err  error
+}
+
+func (e *StickyError) Error() string {
+	return fmt.Sprintf("at bit position %v: %v", e.Tell, e.Err)
+}
+
+func (e *StickyError) Unwrap() error {
+	return e.Err
+}
@@ -77,4 +77,10 @@ type OutBitStream interface {
 
 	// WriteUint8 : Write bits from stream
 	WriteUint8(v uint8) error
+
+	// WriteString : Write octet count using lengthBitCount bits, followed by the UTF-8 octets
+	WriteString(v string, lengthBitCount uint) error
+
+	// WriteBlob : Write octet count using lengthBitCount bits, followed by the octets
+	WriteBlob(octets []byte, lengthBitCount uint) error
 }
@@ -29,8 +29,14 @@ package outbitstream
 import (
 	"encoding/binary"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"strings"
 	"testing"
+
+	"github.com/piot/brook-go/src/debugtag"
+	"github.com/piot/brook-go/src/inbitstream"
 )
 
 func setup() OutBitStream {
@@ -177,3 +183,335 @@ func TestRewind(t *testing.T) {
 		t.Errorf("Expected %d got %d", expected, readFromBuffer)
 	}
 }
+
+func TestWriteString(t *testing.T) {
+	for _, useDebug := range []bool{false, true} {
+		bitstream := NewWithOption(1024, useDebug)
+		writeErr := bitstream.WriteString("brøk", 6)
+		if writeErr != nil {
+			t.Fatal(writeErr)
+		}
+		bitstream.Close()
+
+		in := inbitstream.NewWithOption(bitstream.Octets(), bitstream.Tell(), useDebug)
+		s, readErr := in.ReadString(6, 32)
+		if readErr != nil {
+			t.Fatal(readErr)
+		}
+		if s != "brøk" {
+			t.Errorf("Expected brøk but got %q", s)
+		}
+	}
+
+	tooLongErr := setup().WriteString("brook", 2)
+	if tooLongErr == nil {
+		t.Errorf("Expected error")
+	}
+}
+
+func TestWriteBlob(t *testing.T) {
+	blob := []byte{0xde, 0xad, 0xbe, 0xef, 0x00}
+	for _, useDebug := range []bool{false, true} {
+		bitstream := NewWithOption(1024, useDebug)
+		bitstream.WriteBits(0x5, 3)
+		writeErr := bitstream.WriteBlob(blob, 10)
+		if writeErr != nil {
+			t.Fatal(writeErr)
+		}
+		bitstream.Close()
+
+		in := inbitstream.NewWithOption(bitstream.Octets(), bitstream.Tell(), useDebug)
+		in.ReadBits(3)
+		readBlob, readErr := in.ReadBlob(10, 16)
+		if readErr != nil {
+			t.Fatal(readErr)
+		}
+		if hex.EncodeToString(readBlob) != hex.EncodeToString(blob) {
+			t.Errorf("Expected %X but got %X", blob, readBlob)
+		}
+	}
+}
+
+func writeUint16(out OutBitStream, v uint16) error {
+	return out.WriteUint16(v)
+}
+
+func readUint16(in inbitstream.InBitStream) (uint16, error) {
+	return in.ReadUint16()
+}
+
+func TestWriteCollections(t *testing.T) {
+	bitstream := setup()
+	items := []uint16{1, 0xcafe, 42}
+	WriteSlice(bitstream, items, 4, writeUint16)
+	WriteMap(bitstream, map[uint16]uint16{30: 3, 10: 1, 20: 2}, 4, writeUint16, writeUint16)
+	value := uint16(0xbeef)
+	WriteOptional(bitstream, &value, writeUint16)
+	WriteOptional[uint16](bitstream, nil, writeUint16)
+	bitstream.Close()
+
+	in := inbitstream.New(bitstream.Octets(), bitstream.Tell())
+	readItems, sliceErr := inbitstream.ReadSlice(in, 4, 3, readUint16)
+	if sliceErr != nil {
+		t.Fatal(sliceErr)
+	}
+	if fmt.Sprint(readItems) != fmt.Sprint(items) {
+		t.Errorf("Expected %v but got %v", items, readItems)
+	}
+
+	orderIn := inbitstream.New(bitstream.Octets(), bitstream.Tell())
+	inbitstream.ReadSlice(orderIn, 4, 3, readUint16)
+	firstKey, _ := orderIn.ReadBits(4 + 16)
+	if firstKey != 3<<16|10 {
+		t.Errorf("Expected keys in ascending order, got %X", firstKey)
+	}
+
+	readMap, mapErr := inbitstream.ReadMap(in, 4, 3, readUint16, readUint16)
+	if mapErr != nil {
+		t.Fatal(mapErr)
+	}
+	if len(readMap) != 3 || readMap[20] != 2 {
+		t.Errorf("Wrong map %v", readMap)
+	}
+
+	readValue, optionalErr := inbitstream.ReadOptional(in, readUint16)
+	if optionalErr != nil || readValue == nil || *readValue != value {
+		t.Errorf("Wrong optional %v %v", readValue, optionalErr)
+	}
+	missingValue, missingErr := inbitstream.ReadOptional(in, readUint16)
+	if missingErr != nil || missingValue != nil {
+		t.Errorf("Expected missing optional %v %v", missingValue, missingErr)
+	}
+}
+
+func TestReadSliceMaxCount(t *testing.T) {
+	bitstream := setup()
+	WriteSlice(bitstream, []uint16{1, 2, 3}, 4, writeUint16)
+	bitstream.Close()
+
+	in := inbitstream.New(bitstream.Octets(), bitstream.Tell())
+	_, err := inbitstream.ReadSlice(in, 4, 2, readUint16)
+	if err == nil {
+		t.Errorf("Expected error")
+	}
+}
+
+func TestLabeledDebugStream(t *testing.T) {
+	bitstream := NewLabeledDebugStream(setup())
+	bitstream.PushLabel("player")
+	bitstream.Label("health")
+	bitstream.WriteUint8(100)
+	bitstream.Label("ammo")
+	bitstream.WriteUint8(12)
+	bitstream.PopLabel()
+	bitstream.Close()
+
+	in := inbitstream.NewLabeledDebugStream(inbitstream.New(bitstream.Octets(), bitstream.Tell()))
+	in.PushLabel("player")
+	in.Label("health")
+	health, healthErr := in.ReadUint8()
+	if healthErr != nil || health != 100 {
+		t.Errorf("Wrong health %v %v", health, healthErr)
+	}
+
+	in.Label("armor")
+	_, mismatchErr := in.ReadUint8()
+	if mismatchErr == nil {
+		t.Fatal("Expected label mismatch")
+	}
+	const expected = "field 'player.armor' at bit 35"
+	if !strings.HasPrefix(mismatchErr.Error(), expected) {
+		t.Errorf("Expected %q in %q", expected, mismatchErr.Error())
+	}
+}
+
+func TestDebugFormatVersion2(t *testing.T) {
+	const vectorTag = debugtag.FirstUserTag + 7
+	debugtag.Register(vectorTag, "vector", debugtag.KindMarker)
+
+	legacy := NewDebugStream(setup())
+	if legacy.WriteTypeTag(vectorTag) == nil {
+		t.Errorf("Expected user tag to not fit in version 1")
+	}
+
+	bitstream, formatErr := NewDebugStreamWithFormat(setup(), debugtag.FormatVersion2, true)
+	if formatErr != nil {
+		t.Fatal(formatErr)
+	}
+	bitstream.WriteTypeTag(vectorTag)
+	bitstream.Label("x")
+	bitstream.WriteSignedBits(-5, 10)
+	bitstream.Close()
+
+	in, headerErr := inbitstream.NewDebugStreamFromHeader(inbitstream.New(bitstream.Octets(), bitstream.Tell()))
+	if headerErr != nil {
+		t.Fatal(headerErr)
+	}
+	tagErr := in.CheckTypeTag(vectorTag)
+	if tagErr != nil {
+		t.Error(tagErr)
+	}
+	in.Label("x")
+	v, readErr := in.ReadSignedBits(10)
+	if readErr != nil || v != -5 {
+		t.Errorf("Wrong value %v %v", v, readErr)
+	}
+}
+
+func TestErrorTypes(t *testing.T) {
+	bitstream := New(8)
+	invalidErr := bitstream.WriteBits(0, 33)
+	if !errors.Is(invalidErr, ErrInvalidBitCount) {
+		t.Errorf("Expected invalid bit count error, got %v", invalidErr)
+	}
+
+	bitstream.WriteBits(0, 32)
+	bitstream.WriteBits(0, 32)
+	fullErr := bitstream.WriteBits(0, 32)
+	var bufferFull *BufferFullError
+	if !errors.Is(fullErr, ErrBufferFull) || !errors.As(fullErr, &bufferFull) || bufferFull.Tell != 64 {
+		t.Errorf("Expected buffer full error, got %v", fullErr)
+	}
+
+	seekErr := bitstream.Rewind(100)
+	if !errors.Is(seekErr, ErrSeekOutOfRange) {
+		t.Errorf("Expected seek error, got %v", seekErr)
+	}
+
+	overflowErr := setup().WriteString("brook", 2)
+	if !errors.Is(overflowErr, ErrOverflow) {
+		t.Errorf("Expected overflow error, got %v", overflowErr)
+	}
+
+	_bitstream := setup()
+	debugStream := NewDebugStream(_bitstream)
+	debugStream.WriteUint8(1)
+	in := inbitstream.NewDebugStream(inbitstream.New(debugStream.Octets(), debugStream.Tell()))
+	_, mismatchErr := in.ReadUint16()
+	var mismatch *inbitstream.TypeMismatchError
+	if !errors.Is(mismatchErr, inbitstream.ErrTypeMismatch) || !errors.As(mismatchErr, &mismatch) || mismatch.Received != debugtag.Uint8 {
+		t.Errorf("Expected type mismatch error, got %v", mismatchErr)
+	}
+}
+
+func TestSticky(t *testing.T) {
+	bitstream := NewSticky(New(8))
+	bitstream.WriteUint16(0xcafe)
+	bitstream.WriteUint64(0)
+	bitstream.WriteUint64(0)
+	bitstream.WriteUint8(1)
+	var sticky *StickyError
+	if !errors.As(bitstream.Err(), &sticky) || sticky.Tell != 16 || !errors.Is(bitstream.Err(), ErrBufferFull) {
+		t.Errorf("Expected sticky buffer full error at 16, got %v", bitstream.Err())
+	}
+
+	in := inbitstream.NewSticky(inbitstream.New(bitstream.Octets(), 20))
+	v, _ := in.ReadUint16()
+	in.ReadUint8()
+	in.ReadBits(1)
+	if v != 0xcafe || !errors.Is(in.Err(), io.ErrUnexpectedEOF) {
+		t.Errorf("Expected sticky EOF error, got %X %v", v, in.Err())
+	}
+}
+
+func sourceBit(octets []byte, position uint) uint32 {
+	return uint32(octets[position/8]>>(7-position%8)) & 1
+}
+
+func TestWriteBitsFromStream(t *testing.T) {
+	source := make([]byte, 300)
+	for i := range source {
+		source[i] = byte(i*131 + 7)
+	}
+	for _, sourceOffset := range []uint{0, 1, 7, 8, 13, 32} {
+		for _, targetOffset := range []uint{0, 3, 8, 24, 31, 32, 45} {
+			for _, bitCount := range []uint{0, 9, 127, 128, 129, 200, 1000, 2000} {
+				target := New(512)
+				for written := uint(0); written < targetOffset; written += 16 {
+					target.WriteBits(0x5a5a, min(16, targetOffset-written))
+				}
+				in := inbitstream.New(source, uint(len(source))*8)
+				in.Skip(sourceOffset)
+				err := target.WriteBitsFromStream(in, bitCount)
+				if err != nil {
+					t.Fatal(err)
+				}
+				target.WriteBits(0x3, 2)
+				if in.Tell() != sourceOffset+bitCount {
+					t.Fatalf("Expected source at %v but got %v", sourceOffset+bitCount, in.Tell())
+				}
+
+				octets := target.Octets()
+				for i := uint(0); i < bitCount; i++ {
+					if sourceBit(octets, targetOffset+i) != sourceBit(source, sourceOffset+i) {
+						t.Fatalf("Offsets %v/%v, count %v: bit %v differs", sourceOffset, targetOffset, bitCount, i)
+					}
+				}
+				if sourceBit(octets, targetOffset+bitCount) != 1 || target.Tell() != targetOffset+bitCount+2 {
+					t.Errorf("Offsets %v/%v, count %v: expected the stream to continue after the copied bits", sourceOffset, targetOffset, bitCount)
+				}
+			}
+		}
+	}
+}
+
+func TestWriteBitsFromStreamBufferFull(t *testing.T) {
+	source := make([]byte, 64)
+	target := New(32)
+	in := inbitstream.New(source, 512)
+	err := target.WriteBitsFromStream(in, 512)
+	if !errors.Is(err, ErrBufferFull) {
+		t.Errorf("Expected buffer full but got %v", err)
+	}
+}
+
+func benchmarkWriteBitsFromStream(b *testing.B, octetCount int, targetBitOffset uint, sourceBitOffset uint) {
+	source := make([]byte, octetCount+1)
+	for i := range source {
+		source[i] = byte(i)
+	}
+	bitCount := uint(octetCount) * 8
+	target := New(octetCount + 16)
+	b.SetBytes(int64(octetCount))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		target.Rewind(0)
+		target.WriteBits(0, targetBitOffset)
+		in := inbitstream.New(source, bitCount+sourceBitOffset)
+		in.Skip(sourceBitOffset)
+		if err := target.WriteBitsFromStream(in, bitCount); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkWriteBitsFromStream(b *testing.B) {
+	for _, octetCount := range []int{1 << 10, 16 << 10, 256 << 10, 1 << 20} {
+		b.Run(fmt.Sprintf("%vKB/aligned", octetCount>>10), func(b *testing.B) {
+			benchmarkWriteBitsFromStream(b, octetCount, 0, 0)
+		})
+		b.Run(fmt.Sprintf("%vKB/unaligned", octetCount>>10), func(b *testing.B) {
+			benchmarkWriteBitsFromStream(b, octetCount, 5, 3)
+		})
+	}
+}
+
+func BenchmarkCopyFromOctets(b *testing.B) {
+	for _, octetCount := range []int{1 << 10, 16 << 10, 256 << 10, 1 << 20} {
+		b.Run(fmt.Sprintf("%vKB", octetCount>>10), func(b *testing.B) {
+			source := make([]byte, octetCount)
+			for i := range source {
+				source[i] = byte(i)
+			}
+			target := New(octetCount + 16)
+			b.SetBytes(int64(octetCount))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				target.Rewind(0)
+				if err := CopyFromOctets(target, source, uint(octetCount)*8); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
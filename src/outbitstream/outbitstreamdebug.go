@@ -27,15 +27,70 @@ SOFTWARE.
 package outbitstream
 
 import (
+	"github.com/piot/brook-go/src/debugtag"
 	"github.com/piot/brook-go/src/inbitstream"
 )
 
 type OutBitStreamDebug struct {
-	stream OutBitStream
+	stream      OutBitStream
+	labeled     bool
+	labels      inbitstream.DebugLabelPath
+	tagBitCount uint
 }
 
 func NewDebugStream(stream OutBitStream) *OutBitStreamDebug {
-	return &OutBitStreamDebug{stream: stream}
+	return &OutBitStreamDebug{stream: stream, tagBitCount: 4}
+}
+
+// NewLabeledDebugStream : Debug stream that also writes a hash of the field label for each value
+func NewLabeledDebugStream(stream OutBitStream) *OutBitStreamDebug {
+	return &OutBitStreamDebug{stream: stream, labeled: true, tagBitCount: 4}
+}
+
+// NewDebugStreamWithFormat : Debug stream using the specified format version.
+// From version 2 a header with the version and flags is written first, so the reader can detect the format.
+func NewDebugStreamWithFormat(stream OutBitStream, version debugtag.FormatVersion, labeled bool) (*OutBitStreamDebug, error) {
+	tagBitCount, versionErr := debugtag.TagBitCount(version)
+	if versionErr != nil {
+		return nil, versionErr
+	}
+	o := &OutBitStreamDebug{stream: stream, labeled: labeled, tagBitCount: tagBitCount}
+	if version == debugtag.FormatVersion1 {
+		return o, nil
+	}
+
+	flags := uint32(0)
+	if labeled {
+		flags |= debugtag.FlagLabeled
+	}
+	markerErr := stream.WriteBits(debugtag.HeaderMarker, 8)
+	if markerErr != nil {
+		return nil, markerErr
+	}
+	versionWriteErr := stream.WriteBits(uint32(version), 8)
+	if versionWriteErr != nil {
+		return nil, versionWriteErr
+	}
+	flagsErr := stream.WriteBits(flags, 8)
+	if flagsErr != nil {
+		return nil, flagsErr
+	}
+	return o, nil
+}
+
+// Label : Sets the field name for the next write
+func (o *OutBitStreamDebug) Label(name string) {
+	o.labels.SetLeaf(name)
+}
+
+// PushLabel : Enters a scope in the field path, e.g. a nested struct
+func (o *OutBitStreamDebug) PushLabel(name string) {
+	o.labels.Push(name)
+}
+
+// PopLabel : Leaves the latest scope in the field path
+func (o *OutBitStreamDebug) PopLabel() {
+	o.labels.Pop()
 }
 
 func (o *OutBitStreamDebug) Tell() uint {
@@ -55,7 +110,10 @@ func (o *OutBitStreamDebug) WriteBitsFromStream(in inbitstream.InBitStream, bitC
 }
 
 func (o *OutBitStreamDebug) WriteBits(v uint32, count uint) error {
-	o.writeType(7, count)
+	typeErr := o.writeType(debugtag.Unsigned, count)
+	if typeErr != nil {
+		return typeErr
+	}
 	return o.stream.WriteBits(v, count)
 }
 
@@ -64,43 +122,98 @@ func (o *OutBitStreamDebug) WriteRawBits(v uint32, count uint) error {
 }
 
 func (o *OutBitStreamDebug) WriteSignedBits(v int32, count uint) error {
-	o.writeType(6, count)
+	typeErr := o.writeType(debugtag.Signed, count)
+	if typeErr != nil {
+		return typeErr
+	}
 	return o.stream.WriteSignedBits(v, count)
 }
 
 func (o *OutBitStreamDebug) WriteInt32(v int32) error {
-	o.writeType(9, 32)
+	typeErr := o.writeType(debugtag.Int32, 32)
+	if typeErr != nil {
+		return typeErr
+	}
 	return o.stream.WriteInt32(v)
 }
 
 func (o *OutBitStreamDebug) WriteUint32(v uint32) error {
-	o.writeType(3, 32)
+	typeErr := o.writeType(debugtag.Uint32, 32)
+	if typeErr != nil {
+		return typeErr
+	}
 	return o.stream.WriteUint32(v)
 }
 
 func (o *OutBitStreamDebug) WriteUint64(v uint64) error {
-	o.writeType(4, 64)
+	typeErr := o.writeType(debugtag.Uint64, 64)
+	if typeErr != nil {
+		return typeErr
+	}
 	return o.stream.WriteUint64(v)
 }
 
 func (o *OutBitStreamDebug) WriteUint16(v uint16) error {
-	o.writeType(1, 16)
+	typeErr := o.writeType(debugtag.Uint16, 16)
+	if typeErr != nil {
+		return typeErr
+	}
 	return o.stream.WriteUint16(v)
 }
 
 func (o *OutBitStreamDebug) WriteInt16(v int16) error {
-	o.writeType(2, 16)
+	typeErr := o.writeType(debugtag.Int16, 16)
+	if typeErr != nil {
+		return typeErr
+	}
 	return o.stream.WriteInt16(v)
 }
 
 func (o *OutBitStreamDebug) WriteUint8(v uint8) error {
-	o.writeType(5, 8)
+	typeErr := o.writeType(debugtag.Uint8, 8)
+	if typeErr != nil {
+		return typeErr
+	}
 	return o.stream.WriteUint8(v)
 }
 
-func (o *OutBitStreamDebug) writeType(t int, bitCount uint) {
-	o.stream.WriteBits(uint32(t), 4)
-	o.stream.WriteBits(uint32(bitCount), 7)
+func (o *OutBitStreamDebug) WriteString(v string, lengthBitCount uint) error {
+	typeErr := o.writeType(debugtag.String, lengthBitCount)
+	if typeErr != nil {
+		return typeErr
+	}
+	return o.stream.WriteString(v, lengthBitCount)
+}
+
+func (o *OutBitStreamDebug) WriteBlob(octets []byte, lengthBitCount uint) error {
+	typeErr := o.writeType(debugtag.Blob, lengthBitCount)
+	if typeErr != nil {
+		return typeErr
+	}
+	return o.stream.WriteBlob(octets, lengthBitCount)
+}
+
+// WriteTypeTag : Writes a marker for a user defined type registered in debugtag
+func (o *OutBitStreamDebug) WriteTypeTag(tag debugtag.Tag) error {
+	return o.writeType(tag, 0)
+}
+
+func (o *OutBitStreamDebug) writeType(tag debugtag.Tag, bitCount uint) error {
+	if uint32(tag) > maskFromCount(o.tagBitCount) {
+		return &OverflowError{Value: uint64(tag), BitCount: o.tagBitCount, Tell: o.Tell()}
+	}
+	tagErr := o.stream.WriteBits(uint32(tag), o.tagBitCount)
+	if tagErr != nil {
+		return tagErr
+	}
+	countErr := o.stream.WriteBits(uint32(bitCount), debugtag.CountBitCount)
+	if countErr != nil {
+		return countErr
+	}
+	if o.labeled {
+		return o.stream.WriteBits(uint32(inbitstream.DebugLabelHash(o.labels.Take())), inbitstream.DebugLabelBitCount)
+	}
+	return nil
 }
 
 func (o *OutBitStreamDebug) Octets() []byte {
@@ -31,6 +31,7 @@ import (
 	"encoding/binary"
 	"fmt"
 
+	"github.com/piot/brook-go/src/bits"
 	"github.com/piot/brook-go/src/inbitstream"
 )
 
@@ -66,8 +67,7 @@ func maskFromCount(count uint) uint32 {
 
 func (s *OutBitStreamImpl) writeAccumulatorToArray() error {
 	if s.octetPosition+4 >= uint(len(s.octetArray)) {
-		return fmt.Errorf("write accumulator: octet positions outside octet array (%v out of %v)",
-			s.octetPosition+4, len(s.octetArray))
+		return &BufferFullError{OctetPosition: s.octetPosition + 4, OctetCount: uint(len(s.octetArray)), Tell: s.bitPosition}
 	}
 
 	unusedBitCount := 32 - s.bitsInAccumulator
@@ -90,12 +90,12 @@ func (s *OutBitStreamImpl) Rewind(position uint) error {
 			return fmt.Errorf("rewind: %w", flushErr)
 		}
 	}
-	s.bitPosition = position
 	dwordPosition := position / 32
-	s.octetPosition = dwordPosition * 4
-	if s.octetPosition+4 > uint(len(s.octetArray)) {
-		return fmt.Errorf("seeked too far %v vs %v", position, len(s.octetArray)*8)
+	if dwordPosition*4+4 > uint(len(s.octetArray)) {
+		return &SeekError{Position: position, BitCount: uint(len(s.octetArray)) * 8}
 	}
+	s.bitPosition = position
+	s.octetPosition = dwordPosition * 4
 	a := binary.BigEndian.Uint32(s.octetArray[s.octetPosition : s.octetPosition+4])
 	bitCountToUse := position % 32
 	bitCountToFlush := 32 - bitCountToUse
@@ -112,7 +112,78 @@ func (s *OutBitStreamImpl) Close() {
 	}
 }
 
+// blockCopyMinBitCount : Shorter copies go through WriteBits, since lining up the streams costs more than it saves
+const blockCopyMinBitCount = 128
+
+// WriteBitsFromStream : Copies bits from another stream. Large copies from an InBitStreamImpl are done
+// a word at a time directly between the octet arrays
 func (s *OutBitStreamImpl) WriteBitsFromStream(in inbitstream.InBitStream, bitCount uint) error {
+	blockStream, isBlockStream := in.(*inbitstream.InBitStreamImpl)
+	if !isBlockStream || bitCount < blockCopyMinBitCount {
+		return s.writeBitsFromStreamOneWordAtATime(in, bitCount)
+	}
+
+	// Fill up the accumulator, so the octets can be copied straight into the octet array
+	headBitCount := (32 - s.bitsInAccumulator) % 32
+	headErr := s.writeBitsFromStreamOneWordAtATime(in, headBitCount)
+	if headErr != nil {
+		return headErr
+	}
+	if s.bitsInAccumulator == 32 {
+		flushErr := s.writeAccumulatorToArray()
+		if flushErr != nil {
+			return fmt.Errorf("WriteBitsFromStream: %w", flushErr)
+		}
+		s.octetPosition += 4
+		s.bitsInAccumulator = 0
+		s.ac = 0
+	}
+
+	remainingBitCount := bitCount - headBitCount
+	octetCount := remainingBitCount / 8
+	blockErr := s.writeOctetBlock(blockStream, octetCount)
+	if blockErr != nil {
+		return blockErr
+	}
+
+	return s.writeBitsFromStreamOneWordAtATime(in, remainingBitCount%8)
+}
+
+// writeOctetBlock : Copies octetCount octets when the accumulator is empty. Whole words are
+// copied to the octet array and the octets that are left over are put in the accumulator
+func (s *OutBitStreamImpl) writeOctetBlock(in *inbitstream.InBitStreamImpl, octetCount uint) error {
+	wordOctetCount := octetCount / 4 * 4
+	if wordOctetCount > 0 && s.octetPosition+wordOctetCount >= uint(len(s.octetArray)) {
+		return &BufferFullError{OctetPosition: s.octetPosition + wordOctetCount, OctetCount: uint(len(s.octetArray)), Tell: s.bitPosition}
+	}
+	source, bitOffset, readErr := in.ReadBlock(octetCount * 8)
+	if readErr != nil {
+		return readErr
+	}
+
+	target := s.octetArray[s.octetPosition : s.octetPosition+wordOctetCount]
+	if bitOffset == 0 {
+		copy(target, source)
+	} else {
+		bits.CopyShifted(target, source, bitOffset)
+	}
+	for i := wordOctetCount; i < octetCount; i++ {
+		s.ac = s.ac<<8 | uint32(shiftedOctet(source, i, bitOffset))
+	}
+	s.bitsInAccumulator = (octetCount - wordOctetCount) * 8
+	s.octetPosition += wordOctetCount
+	s.bitPosition += octetCount * 8
+	return nil
+}
+
+func shiftedOctet(source []byte, index uint, bitOffset uint) byte {
+	if bitOffset == 0 {
+		return source[index]
+	}
+	return source[index]<<bitOffset | source[index+1]>>(8-bitOffset)
+}
+
+func (s *OutBitStreamImpl) writeBitsFromStreamOneWordAtATime(in inbitstream.InBitStream, bitCount uint) error {
 	lastBitCount := uint(bitCount % 32)
 	for i := uint(0); i < bitCount/32; i++ {
 		data, readErr := in.ReadRawBits(32)
@@ -155,7 +226,7 @@ func (s *OutBitStreamImpl) WriteRawBits(v uint32, count uint) error {
 // WriteBits : Write bits to stream
 func (s *OutBitStreamImpl) WriteBits(v uint32, count uint) error {
 	if count > 32 {
-		return fmt.Errorf("Max 32 bits to write")
+		return &InvalidBitCountError{Count: count, Max: 32, Tell: s.bitPosition}
 	}
 
 	bitCountLeftInAc := 32 - s.bitsInAccumulator
@@ -213,7 +284,10 @@ func (s *OutBitStreamImpl) WriteUint32(v uint32) error {
 // WriteUint64 : Write bits to stream
 func (s *OutBitStreamImpl) WriteUint64(v uint64) error {
 	upper := uint32(v >> 32)
-	s.WriteBits(upper, 32)
+	upperErr := s.WriteBits(upper, 32)
+	if upperErr != nil {
+		return upperErr
+	}
 	lower := uint32(v & 0xffffffff)
 	return s.WriteBits(lower, 32)
 }
@@ -233,6 +307,32 @@ func (s *OutBitStreamImpl) WriteUint8(v uint8) error {
 	return s.WriteBits(uint32(v), 8)
 }
 
+// WriteString : Write octet count using lengthBitCount bits, followed by the UTF-8 octets
+func (s *OutBitStreamImpl) WriteString(v string, lengthBitCount uint) error {
+	return s.WriteBlob([]byte(v), lengthBitCount)
+}
+
+// WriteBlob : Write octet count using lengthBitCount bits, followed by the octets
+func (s *OutBitStreamImpl) WriteBlob(octets []byte, lengthBitCount uint) error {
+	if lengthBitCount > 32 {
+		return &InvalidBitCountError{Count: lengthBitCount, Max: 32, Tell: s.bitPosition}
+	}
+	if uint64(len(octets)) > uint64(maskFromCount(lengthBitCount)) {
+		return &OverflowError{Value: uint64(len(octets)), BitCount: lengthBitCount, Tell: s.bitPosition}
+	}
+	lengthErr := s.WriteBits(uint32(len(octets)), lengthBitCount)
+	if lengthErr != nil {
+		return lengthErr
+	}
+	for _, octet := range octets {
+		octetErr := s.WriteBits(uint32(octet), 8)
+		if octetErr != nil {
+			return octetErr
+		}
+	}
+	return nil
+}
+
 func (s *OutBitStreamImpl) Octets() []byte {
 	s.writeAccumulatorToArray()
 	octetCountWrittenTo := (s.bitPosition + 7) / 8
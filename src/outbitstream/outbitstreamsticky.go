@@ -0,0 +1,129 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package outbitstream ...
+package outbitstream
+
+import (
+	"github.com/piot/brook-go/src/inbitstream"
+)
+
+// OutBitStreamSticky : Remembers the first error, after which all writes are ignored.
+// Check Err() once after all writes are done.
+type OutBitStreamSticky struct {
+	stream OutBitStream
+	err    error
+}
+
+// NewSticky : Creates a sticky error wrapper around an output bit stream
+func NewSticky(stream OutBitStream) *OutBitStreamSticky {
+	return &OutBitStreamSticky{stream: stream}
+}
+
+// Err : The first error as a *StickyError, or nil
+func (o *OutBitStreamSticky) Err() error {
+	return o.err
+}
+
+func (o *OutBitStreamSticky) write(writeFunc func() error) error {
+	if o.err != nil {
+		return o.err
+	}
+	tell := o.stream.Tell()
+	err := writeFunc()
+	if err != nil {
+		o.err = &StickyError{Tell: tell, Err: err}
+	}
+	return o.err
+}
+
+func (o *OutBitStreamSticky) Tell() uint {
+	return o.stream.Tell()
+}
+
+func (o *OutBitStreamSticky) Rewind(position uint) error {
+	return o.write(func() error { return o.stream.Rewind(position) })
+}
+
+func (o *OutBitStreamSticky) Close() {
+	o.stream.Close()
+}
+
+func (o *OutBitStreamSticky) Octets() []byte {
+	return o.stream.Octets()
+}
+
+func (o *OutBitStreamSticky) CopyOctets(target []byte) uint {
+	return o.stream.CopyOctets(target)
+}
+
+func (o *OutBitStreamSticky) WriteBitsFromStream(in inbitstream.InBitStream, bitCount uint) error {
+	return o.write(func() error { return o.stream.WriteBitsFromStream(in, bitCount) })
+}
+
+func (o *OutBitStreamSticky) WriteBits(v uint32, count uint) error {
+	return o.write(func() error { return o.stream.WriteBits(v, count) })
+}
+
+func (o *OutBitStreamSticky) WriteRawBits(v uint32, count uint) error {
+	return o.write(func() error { return o.stream.WriteRawBits(v, count) })
+}
+
+func (o *OutBitStreamSticky) WriteSignedBits(v int32, count uint) error {
+	return o.write(func() error { return o.stream.WriteSignedBits(v, count) })
+}
+
+func (o *OutBitStreamSticky) WriteUint64(v uint64) error {
+	return o.write(func() error { return o.stream.WriteUint64(v) })
+}
+
+func (o *OutBitStreamSticky) WriteInt32(v int32) error {
+	return o.write(func() error { return o.stream.WriteInt32(v) })
+}
+
+func (o *OutBitStreamSticky) WriteUint32(v uint32) error {
+	return o.write(func() error { return o.stream.WriteUint32(v) })
+}
+
+func (o *OutBitStreamSticky) WriteUint16(v uint16) error {
+	return o.write(func() error { return o.stream.WriteUint16(v) })
+}
+
+func (o *OutBitStreamSticky) WriteInt16(v int16) error {
+	return o.write(func() error { return o.stream.WriteInt16(v) })
+}
+
+func (o *OutBitStreamSticky) WriteUint8(v uint8) error {
+	return o.write(func() error { return o.stream.WriteUint8(v) })
+}
+
+func (o *OutBitStreamSticky) WriteString(v string, lengthBitCount uint) error {
+	return o.write(func() error { return o.stream.WriteString(v, lengthBitCount) })
+}
+
+func (o *OutBitStreamSticky) WriteBlob(octets []byte, lengthBitCount uint) error {
+	return o.write(func() error { return o.stream.WriteBlob(octets, lengthBitCount) })
+}
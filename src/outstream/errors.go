@@ -0,0 +1,99 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package outstream ...
+package outstream
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrOverflow : A length does not fit in the length prefix
+	ErrOverflow = errors.New("overflow")
+	// ErrInvalidLengthPrefix : The length prefix octet count is not supported
+	ErrInvalidLengthPrefix = errors.New("invalid length prefix")
+	// ErrBufferFull : Not all octets could be written
+	ErrBufferFull = errors.New("buffer full")
+)
+
+// OverflowError : A length does not fit in the length prefix
+type OverflowError struct {
+	Value      int
+	OctetCount int
+	Tell       int
+}
+
+func (e *OverflowError) Error() string {
+	return fmt.Sprintf("length %v does not fit in %v octets at position:%v", e.Value, e.OctetCount, e.Tell)
+}
+
+func (e *OverflowError) Is(target error) bool {
+	return target == ErrOverflow
+}
+
+// InvalidLengthPrefixError : The length prefix octet count is not supported
+type InvalidLengthPrefixError struct {
+	OctetCount int
+}
+
+func (e *InvalidLengthPrefixError) Error() string {
+	return fmt.Sprintf("length prefix must be 1, 2 or 4 octets, not %v", e.OctetCount)
+}
+
+func (e *InvalidLengthPrefixError) Is(target error) bool {
+	return target == ErrInvalidLengthPrefix
+}
+
+// BufferFullError : Not all octets could be written
+type BufferFullError struct {
+	Written int
+	Count   int
+	Tell    int
+}
+
+func (e *BufferFullError) Error() string {
+	return fmt.Sprintf("couldn't write all octets (%v out of %v) at position:%v", e.Written, e.Count, e.Tell)
+}
+
+func (e *BufferFullError) Is(target error) bool {
+	return target == ErrBufferFull
+}
+
+// StickyError : First error in a sticky stream and the octet position where it happened
+type StickyError struct {
+	Tell int
+	Err  error
+}
+
+func (e *StickyError) Error() string {
+	return fmt.Sprintf("at octet position %v: %v", e.Tell, e.Err)
+}
+
+func (e *StickyError) Unwrap() error {
+	return e.Err
+}
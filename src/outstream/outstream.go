@@ -30,8 +30,8 @@ package outstream
 import (
 	"bytes"
 	"encoding/binary"
-	"errors"
 	"fmt"
+	"math"
 )
 
 // OutStream : Write to octet stream
@@ -54,8 +54,7 @@ func (s *OutStream) Feed(octets []byte) error {
 	}
 
 	if lengthWritten != len(octets) {
-		err := errors.New("couldn't write all octets")
-		return err
+		return &BufferFullError{Written: lengthWritten, Count: len(octets), Tell: s.position}
 	}
 	s.position += len(octets)
 	return nil
@@ -97,6 +96,41 @@ func (s *OutStream) WriteUint8(v uint8) error {
 	return err
 }
 
+func (s *OutStream) writeLength(length int, lengthOctetCount int) error {
+	switch lengthOctetCount {
+	case 1:
+		if length > math.MaxUint8 {
+			return &OverflowError{Value: length, OctetCount: lengthOctetCount, Tell: s.position}
+		}
+		return s.WriteUint8(uint8(length))
+	case 2:
+		if length > math.MaxUint16 {
+			return &OverflowError{Value: length, OctetCount: lengthOctetCount, Tell: s.position}
+		}
+		return s.WriteUint16(uint16(length))
+	case 4:
+		if uint64(length) > math.MaxUint32 {
+			return &OverflowError{Value: length, OctetCount: lengthOctetCount, Tell: s.position}
+		}
+		return s.WriteUint32(uint32(length))
+	}
+	return &InvalidLengthPrefixError{OctetCount: lengthOctetCount}
+}
+
+// WriteString : Writes the octet count using lengthOctetCount (1, 2 or 4) octets followed by the UTF-8 octets
+func (s *OutStream) WriteString(v string, lengthOctetCount int) error {
+	return s.WriteBlob([]byte(v), lengthOctetCount)
+}
+
+// WriteBlob : Writes the octet count using lengthOctetCount (1, 2 or 4) octets followed by the octets
+func (s *OutStream) WriteBlob(octets []byte, lengthOctetCount int) error {
+	lengthErr := s.writeLength(len(octets), lengthOctetCount)
+	if lengthErr != nil {
+		return lengthErr
+	}
+	return s.Feed(octets)
+}
+
 // WriteOctets : Writes octets to stream
 func (s *OutStream) WriteOctets(octets []byte) error {
 	return s.Feed(octets)
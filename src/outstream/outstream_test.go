@@ -28,6 +28,7 @@ package outstream
 
 import (
 	"bytes"
+	"errors"
 	"testing"
 )
 
@@ -50,3 +51,63 @@ func Test16and32bit(t *testing.T) {
 		t.Errorf("Not equal")
 	}
 }
+
+func TestWriteString(t *testing.T) {
+	stream := New()
+	err := stream.WriteString("brook", 2)
+	if err != nil {
+		t.Error(err)
+	}
+
+	expected := []byte{0x00, 0x05, 'b', 'r', 'o', 'o', 'k'}
+	if !bytes.Equal(expected, stream.Octets()) {
+		t.Errorf("Not equal %v", stream.Octets())
+	}
+
+	tooLongErr := stream.WriteString(string(make([]byte, 256)), 1)
+	if tooLongErr == nil {
+		t.Errorf("Should have failed...")
+	}
+}
+
+func TestWriteBlob(t *testing.T) {
+	stream := New()
+	err := stream.WriteBlob([]byte{0xca, 0xfe}, 4)
+	if err != nil {
+		t.Error(err)
+	}
+
+	expected := []byte{0x00, 0x00, 0x00, 0x02, 0xca, 0xfe}
+	if !bytes.Equal(expected, stream.Octets()) {
+		t.Errorf("Not equal %v", stream.Octets())
+	}
+}
+
+func TestErrorTypes(t *testing.T) {
+	stream := New()
+	stream.WriteUint8(1)
+	overflowErr := stream.WriteBlob(make([]byte, 0x10000), 2)
+	var overflow *OverflowError
+	if !errors.Is(overflowErr, ErrOverflow) || !errors.As(overflowErr, &overflow) || overflow.Tell != 1 {
+		t.Errorf("Expected overflow error, got %v", overflowErr)
+	}
+
+	prefixErr := stream.WriteBlob(nil, 3)
+	if !errors.Is(prefixErr, ErrInvalidLengthPrefix) {
+		t.Errorf("Expected invalid length prefix error, got %v", prefixErr)
+	}
+}
+
+func TestSticky(t *testing.T) {
+	stream := NewSticky(New())
+	stream.WriteUint16(0xcafe)
+	stream.WriteString("brook", 3)
+	stream.WriteUint8(1)
+	if len(stream.Octets()) != 2 {
+		t.Errorf("Expected writes to stop after error")
+	}
+	var sticky *StickyError
+	if !errors.As(stream.Err(), &sticky) || sticky.Tell != 2 || !errors.Is(stream.Err(), ErrInvalidLengthPrefix) {
+		t.Errorf("Expected sticky error at 2, got %v", stream.Err())
+	}
+}
@@ -0,0 +1,106 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package outstream ...
+package outstream
+
+// OutStreamSticky : Remembers the first error, after which all writes are ignored.
+// Check Err() once after all writes are done.
+type OutStreamSticky struct {
+	stream *OutStream
+	err    error
+}
+
+// NewSticky : Creates a sticky error wrapper around an output stream
+func NewSticky(stream *OutStream) *OutStreamSticky {
+	return &OutStreamSticky{stream: stream}
+}
+
+// Err : The first error as a *StickyError, or nil
+func (s *OutStreamSticky) Err() error {
+	return s.err
+}
+
+func (s *OutStreamSticky) write(writeFunc func() error) error {
+	if s.err != nil {
+		return s.err
+	}
+	tell := s.stream.Tell()
+	err := writeFunc()
+	if err != nil {
+		s.err = &StickyError{Tell: tell, Err: err}
+	}
+	return s.err
+}
+
+func (s *OutStreamSticky) Tell() int {
+	return s.stream.Tell()
+}
+
+// Feed : Adds octets to stream
+func (s *OutStreamSticky) Feed(octets []byte) error {
+	return s.write(func() error { return s.stream.Feed(octets) })
+}
+
+// WriteUint64 : Writes an unsigned 64-bit integer to stream
+func (s *OutStreamSticky) WriteUint64(v uint64) error {
+	return s.write(func() error { return s.stream.WriteUint64(v) })
+}
+
+// WriteUint32 : Writes an unsigned 32-bit integer to stream
+func (s *OutStreamSticky) WriteUint32(v uint32) error {
+	return s.write(func() error { return s.stream.WriteUint32(v) })
+}
+
+// WriteUint16 : Writes an unsigned 16-bit integer to stream
+func (s *OutStreamSticky) WriteUint16(v uint16) error {
+	return s.write(func() error { return s.stream.WriteUint16(v) })
+}
+
+// WriteUint8 : Writes an octet to stream
+func (s *OutStreamSticky) WriteUint8(v uint8) error {
+	return s.write(func() error { return s.stream.WriteUint8(v) })
+}
+
+// WriteOctets : Writes octets to stream
+func (s *OutStreamSticky) WriteOctets(octets []byte) error {
+	return s.write(func() error { return s.stream.WriteOctets(octets) })
+}
+
+// WriteString : Writes a length prefixed string to stream
+func (s *OutStreamSticky) WriteString(v string, lengthOctetCount int) error {
+	return s.write(func() error { return s.stream.WriteString(v, lengthOctetCount) })
+}
+
+// WriteBlob : Writes length prefixed octets to stream
+func (s *OutStreamSticky) WriteBlob(octets []byte, lengthOctetCount int) error {
+	return s.write(func() error { return s.stream.WriteBlob(octets, lengthOctetCount) })
+}
+
+// Octets : Gets the written octets
+func (s *OutStreamSticky) Octets() []byte {
+	return s.stream.Octets()
+}
@@ -0,0 +1,128 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package packedarray stores integers with a fixed bit count back to back, using the same MSB-first
+// layout as the bit streams, e.g. for tile maps and large lookup tables.
+package packedarray
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/piot/brook-go/src/inbitstream"
+	"github.com/piot/brook-go/src/outbitstream"
+)
+
+// MaxBitCount : Largest bit count for each value
+const MaxBitCount = 32
+
+// wordPadding : Extra octets after the values, so that every value can be reached with one 64-bit load
+const wordPadding = 7
+
+// PackedArray : Fixed length array of values with bitCount bits each
+type PackedArray struct {
+	octets   []byte
+	length   uint
+	bitCount uint
+}
+
+// New : Creates an array of length values with bitCount bits each, all set to zero
+func New(length uint, bitCount uint) (*PackedArray, error) {
+	if bitCount == 0 || bitCount > MaxBitCount {
+		return nil, fmt.Errorf("packedarray: bit count %v must be in the range 1..%v", bitCount, MaxBitCount)
+	}
+	octetCount := (length*bitCount + 7) / 8
+	return &PackedArray{octets: make([]byte, octetCount+wordPadding), length: length, bitCount: bitCount}, nil
+}
+
+// Len : Number of values in the array
+func (a *PackedArray) Len() uint {
+	return a.length
+}
+
+// BitCount : Number of bits for each value
+func (a *PackedArray) BitCount() uint {
+	return a.bitCount
+}
+
+// TotalBitCount : Number of bits for all the values
+func (a *PackedArray) TotalBitCount() uint {
+	return a.length * a.bitCount
+}
+
+// Octets : The packed values, laid out as if they were written to an OutBitStream one by one
+func (a *PackedArray) Octets() []byte {
+	return a.octets[:len(a.octets)-wordPadding]
+}
+
+func (a *PackedArray) mask() uint64 {
+	return (uint64(1) << a.bitCount) - 1
+}
+
+// location : Octet holding the first bit of the value and how far the value must be shifted down in the 64-bit word
+func (a *PackedArray) location(index uint) (uint, uint) {
+	if index >= a.length {
+		panic(fmt.Sprintf("packedarray: index %v out of range %v", index, a.length))
+	}
+	bitPosition := index * a.bitCount
+	return bitPosition / 8, 64 - bitPosition%8 - a.bitCount
+}
+
+// Get : The value at index
+func (a *PackedArray) Get(index uint) uint32 {
+	octetPosition, shift := a.location(index)
+	word := binary.BigEndian.Uint64(a.octets[octetPosition:])
+	return uint32((word >> shift) & a.mask())
+}
+
+// Set : Sets the value at index. The value must fit in the bit count
+func (a *PackedArray) Set(index uint, v uint32) {
+	if uint64(v) > a.mask() {
+		panic(fmt.Sprintf("packedarray: value %v does not fit in %v bits", v, a.bitCount))
+	}
+	octetPosition, shift := a.location(index)
+	word := binary.BigEndian.Uint64(a.octets[octetPosition:])
+	word = word&^(a.mask()<<shift) | uint64(v)<<shift
+	binary.BigEndian.PutUint64(a.octets[octetPosition:], word)
+}
+
+// Write : Writes all the values to the stream as one block of bits
+func Write(targetStream outbitstream.OutBitStream, a *PackedArray) error {
+	return outbitstream.CopyFromOctets(targetStream, a.Octets(), a.TotalBitCount())
+}
+
+// Read : Reads length values with bitCount bits each, written by Write
+func Read(sourceStream inbitstream.InBitStream, length uint, bitCount uint) (*PackedArray, error) {
+	a, newErr := New(length, bitCount)
+	if newErr != nil {
+		return nil, newErr
+	}
+	copyErr := inbitstream.CopyToOctets(sourceStream, a.Octets(), a.TotalBitCount())
+	if copyErr != nil {
+		return nil, copyErr
+	}
+	return a, nil
+}
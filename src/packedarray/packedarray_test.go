@@ -0,0 +1,162 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package packedarray
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/piot/brook-go/src/inbitstream"
+	"github.com/piot/brook-go/src/outbitstream"
+)
+
+func TestGetSet(t *testing.T) {
+	for bitCount := uint(1); bitCount <= MaxBitCount; bitCount++ {
+		random := rand.New(rand.NewSource(int64(bitCount)))
+		a, err := New(100, bitCount)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mask := uint32((uint64(1) << bitCount) - 1)
+		values := make([]uint32, a.Len())
+		for i := range values {
+			values[i] = random.Uint32() & mask
+			a.Set(uint(i), values[i])
+		}
+		// Overwrite every other value to check that neighbours are kept
+		for i := 0; i < len(values); i += 2 {
+			values[i] = mask - values[i]
+			a.Set(uint(i), values[i])
+		}
+		for i, v := range values {
+			if a.Get(uint(i)) != v {
+				t.Fatalf("Bit count %v, index %v: expected %v but got %v", bitCount, i, v, a.Get(uint(i)))
+			}
+		}
+	}
+}
+
+func TestSameLayoutAsBitStream(t *testing.T) {
+	a, _ := New(5, 7)
+	out := outbitstream.New(16)
+	for i := uint(0); i < a.Len(); i++ {
+		v := uint32(i*25 + 3)
+		a.Set(i, v)
+		out.WriteBits(v, 7)
+	}
+	expected := out.Octets()
+	octets := a.Octets()
+	if len(octets) != len(expected) {
+		t.Fatalf("Expected %v octets but got %v", len(expected), len(octets))
+	}
+	for i := range expected {
+		if octets[i] != expected[i] {
+			t.Errorf("Octet %v: expected %02x but got %02x", i, expected[i], octets[i])
+		}
+	}
+}
+
+func TestStreamRoundTrip(t *testing.T) {
+	random := rand.New(rand.NewSource(49))
+	a, _ := New(1000, 11)
+	for i := uint(0); i < a.Len(); i++ {
+		a.Set(i, uint32(random.Intn(2048)))
+	}
+
+	out := outbitstream.New(2048)
+	out.WriteBits(5, 3)
+	err := Write(out, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out.WriteBits(1, 1)
+	if out.Tell() != 3+a.TotalBitCount()+1 {
+		t.Errorf("Expected %v bits but got %v", 3+a.TotalBitCount()+1, out.Tell())
+	}
+
+	in := inbitstream.New(out.Octets(), out.Tell())
+	in.ReadBits(3)
+	readArray, readErr := Read(in, 1000, 11)
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	for i := uint(0); i < a.Len(); i++ {
+		if readArray.Get(i) != a.Get(i) {
+			t.Fatalf("Index %v: expected %v but got %v", i, a.Get(i), readArray.Get(i))
+		}
+	}
+	last, _ := in.ReadBits(1)
+	if last != 1 || !in.IsEOF() {
+		t.Errorf("Expected the bit after the array to be read last")
+	}
+}
+
+func TestStreamRoundTripOverDebugStream(t *testing.T) {
+	a, _ := New(100, 7)
+	for i := uint(0); i < a.Len(); i++ {
+		a.Set(i, uint32(i))
+	}
+
+	out := outbitstream.NewDebugStream(outbitstream.New(2048))
+	out.WriteBits(5, 3)
+	if err := Write(out, a); err != nil {
+		t.Fatal(err)
+	}
+	out.WriteBits(1, 1)
+
+	in := inbitstream.NewDebugStream(inbitstream.New(out.Octets(), out.Tell()))
+	in.ReadBits(3)
+	readArray, readErr := Read(in, 100, 7)
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	for i := uint(0); i < a.Len(); i++ {
+		if readArray.Get(i) != a.Get(i) {
+			t.Fatalf("Index %v: expected %v but got %v", i, a.Get(i), readArray.Get(i))
+		}
+	}
+	last, lastErr := in.ReadBits(1)
+	if lastErr != nil || last != 1 {
+		t.Errorf("Expected the bit after the array to be read last (%v)", lastErr)
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	if _, err := New(10, 0); err == nil {
+		t.Errorf("Expected error for zero bit count")
+	}
+	if _, err := New(10, 33); err == nil {
+		t.Errorf("Expected error for too large bit count")
+	}
+	a, _ := New(10, 4)
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected panic for too large value")
+		}
+	}()
+	a.Set(0, 16)
+}
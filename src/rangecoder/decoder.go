@@ -0,0 +1,94 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package rangecoder ...
+package rangecoder
+
+import (
+	"github.com/piot/brook-go/src/inbitstream"
+)
+
+// Decoder : Range decoder that reads octets written by Encoder from a bit stream
+type Decoder struct {
+	in   inbitstream.InBitStream
+	rng  uint32
+	code uint32
+}
+
+// NewDecoder : Creates a decoder and reads the first octets from in
+func NewDecoder(in inbitstream.InBitStream) (*Decoder, error) {
+	d := &Decoder{in: in, rng: 0xffffffff}
+	for i := 0; i < 5; i++ {
+		octet, readErr := in.ReadBits(8)
+		if readErr != nil {
+			return nil, readErr
+		}
+		d.code = d.code<<8 | octet
+	}
+	return d, nil
+}
+
+// DecodeBit : Decodes a bit and adapts the probability the same way as the encoder
+func (d *Decoder) DecodeBit(p *Probability) (uint32, error) {
+	bound := (d.rng >> probabilityBitCount) * uint32(*p)
+	var bit uint32
+	if d.code < bound {
+		d.rng = bound
+	} else {
+		d.rng -= bound
+		d.code -= bound
+		bit = 1
+	}
+	p.update(bit)
+	if d.rng < topValue {
+		d.rng <<= 8
+		octet, readErr := d.in.ReadBits(8)
+		if readErr != nil {
+			return 0, readErr
+		}
+		d.code = d.code<<8 | octet
+	}
+	return bit, nil
+}
+
+// DecodeBool : Decodes a boolean
+func (d *Decoder) DecodeBool(p *Probability) (bool, error) {
+	bit, err := d.DecodeBit(p)
+	return bit != 0, err
+}
+
+// DecodeSymbol : Decodes a symbol with the bit tree model
+func (d *Decoder) DecodeSymbol(t *BitTree) (uint32, error) {
+	node := uint32(1)
+	for i := uint(0); i < t.bitCount; i++ {
+		bit, decodeErr := d.DecodeBit(&t.probabilities[node])
+		if decodeErr != nil {
+			return 0, decodeErr
+		}
+		node = node<<1 | bit
+	}
+	return node - (1 << t.bitCount), nil
+}
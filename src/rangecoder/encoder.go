@@ -0,0 +1,125 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package rangecoder ...
+package rangecoder
+
+import (
+	"fmt"
+
+	"github.com/piot/brook-go/src/outbitstream"
+)
+
+// Encoder : Range encoder that writes octets to a bit stream. Call Close when done
+type Encoder struct {
+	out       outbitstream.OutBitStream
+	low       uint64
+	rng       uint32
+	cache     uint8
+	cacheSize int64
+}
+
+// NewEncoder : Creates an encoder writing to out
+func NewEncoder(out outbitstream.OutBitStream) *Encoder {
+	return &Encoder{out: out, rng: 0xffffffff, cacheSize: 1}
+}
+
+// shiftLow writes the top octet of low, delaying 0xff octets until it is known if a carry reaches them
+func (e *Encoder) shiftLow() error {
+	if uint32(e.low) < 0xff000000 || e.low>>32 != 0 {
+		carry := uint8(e.low >> 32)
+		octet := e.cache
+		for {
+			writeErr := e.out.WriteBits(uint32(octet+carry), 8)
+			if writeErr != nil {
+				return writeErr
+			}
+			octet = 0xff
+			e.cacheSize--
+			if e.cacheSize == 0 {
+				break
+			}
+		}
+		e.cache = uint8(e.low >> 24)
+	}
+	e.cacheSize++
+	e.low = (e.low & 0x00ffffff) << 8
+	return nil
+}
+
+// EncodeBit : Encodes bit (0 or 1) and adapts the probability
+func (e *Encoder) EncodeBit(p *Probability, bit uint32) error {
+	bound := (e.rng >> probabilityBitCount) * uint32(*p)
+	if bit == 0 {
+		e.rng = bound
+	} else {
+		e.low += uint64(bound)
+		e.rng -= bound
+	}
+	p.update(bit)
+	if e.rng < topValue {
+		e.rng <<= 8
+		return e.shiftLow()
+	}
+	return nil
+}
+
+// EncodeBool : Encodes a boolean and adapts the probability
+func (e *Encoder) EncodeBool(p *Probability, v bool) error {
+	bit := uint32(0)
+	if v {
+		bit = 1
+	}
+	return e.EncodeBit(p, bit)
+}
+
+// EncodeSymbol : Encodes a symbol with the bit tree model, most significant bit first
+func (e *Encoder) EncodeSymbol(t *BitTree, symbol uint32) error {
+	if symbol>>t.bitCount != 0 {
+		return fmt.Errorf("rangecoder: symbol %v does not fit in %v bits", symbol, t.bitCount)
+	}
+	node := uint32(1)
+	for i := int(t.bitCount) - 1; i >= 0; i-- {
+		bit := (symbol >> uint(i)) & 1
+		encodeErr := e.EncodeBit(&t.probabilities[node], bit)
+		if encodeErr != nil {
+			return encodeErr
+		}
+		node = node<<1 | bit
+	}
+	return nil
+}
+
+// Close : Flushes the remaining state. Must be called once after the last symbol
+func (e *Encoder) Close() error {
+	for i := 0; i < 5; i++ {
+		shiftErr := e.shiftLow()
+		if shiftErr != nil {
+			return shiftErr
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package rangecoder is an adaptive binary range coder in the style of LZMA.
+// Every bit is coded with a probability that adapts to the bits seen so far, so heavily skewed
+// booleans and small enums use a fraction of a bit each.
+package rangecoder
+
+const (
+	probabilityBitCount = 11
+	probabilityOne      = 1 << probabilityBitCount
+	adaptShift          = 5
+	topValue            = 1 << 24
+)
+
+// Probability : Adaptive probability that the next bit is zero, in units of 1/2048
+type Probability uint16
+
+// NewProbability : Probability that starts out at 50%
+func NewProbability() Probability {
+	return probabilityOne / 2
+}
+
+// NewProbabilities : Probabilities for count contexts, e.g. indexed by the previously coded value
+func NewProbabilities(count int) []Probability {
+	probabilities := make([]Probability, count)
+	for i := range probabilities {
+		probabilities[i] = NewProbability()
+	}
+	return probabilities
+}
+
+func (p *Probability) update(bit uint32) {
+	if bit == 0 {
+		*p += (probabilityOne - *p) >> adaptShift
+	} else {
+		*p -= *p >> adaptShift
+	}
+}
+
+// BitTree : Model for symbols with a fixed bit count, e.g. small enums. Each bit is coded with
+// the higher bits of the symbol as context
+type BitTree struct {
+	bitCount      uint
+	probabilities []Probability
+}
+
+// NewBitTree : Model for symbols in the range 0..(1<<bitCount)-1
+func NewBitTree(bitCount uint) *BitTree {
+	return &BitTree{bitCount: bitCount, probabilities: NewProbabilities(1 << bitCount)}
+}
+
+// BitCount : Number of bits in each symbol
+func (t *BitTree) BitCount() uint {
+	return t.bitCount
+}
@@ -0,0 +1,175 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package rangecoder
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/piot/brook-go/src/inbitstream"
+	"github.com/piot/brook-go/src/outbitstream"
+)
+
+func skewedBools(count int, trueChance float64) []bool {
+	random := rand.New(rand.NewSource(42))
+	values := make([]bool, count)
+	for i := range values {
+		values[i] = random.Float64() < trueChance
+	}
+	return values
+}
+
+func TestSkewedBools(t *testing.T) {
+	values := skewedBools(2000, 0.05)
+
+	out := outbitstream.New(2048)
+	encoder := NewEncoder(out)
+	p := NewProbability()
+	for _, v := range values {
+		if err := encoder.EncodeBool(&p, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if out.Tell() > uint(len(values))/2 {
+		t.Errorf("Expected at least 50%% savings but used %v bits for %v booleans", out.Tell(), len(values))
+	}
+
+	decoder, decoderErr := NewDecoder(inbitstream.New(out.Octets(), out.Tell()))
+	if decoderErr != nil {
+		t.Fatal(decoderErr)
+	}
+	p = NewProbability()
+	for i, expected := range values {
+		v, err := decoder.DecodeBool(&p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != expected {
+			t.Fatalf("Index %v: expected %v", i, expected)
+		}
+	}
+}
+
+func TestSymbolsWithContext(t *testing.T) {
+	random := rand.New(rand.NewSource(7))
+	symbols := make([]uint32, 3000)
+	previous := uint32(0)
+	for i := range symbols {
+		// Mostly repeats the previous symbol
+		if random.Intn(10) == 0 {
+			previous = uint32(random.Intn(6))
+		}
+		symbols[i] = previous
+	}
+
+	out := outbitstream.New(4096)
+	encoder := NewEncoder(out)
+	trees := []*BitTree{}
+	for context := 0; context < 8; context++ {
+		trees = append(trees, NewBitTree(3))
+	}
+	context := uint32(0)
+	for _, symbol := range symbols {
+		if err := encoder.EncodeSymbol(trees[context], symbol); err != nil {
+			t.Fatal(err)
+		}
+		context = symbol
+	}
+	encoder.Close()
+	if out.Tell() > uint(len(symbols))*3/2 {
+		t.Errorf("Expected at least 50%% savings but used %v bits for %v symbols of 3 bits", out.Tell(), len(symbols))
+	}
+
+	decoder, _ := NewDecoder(inbitstream.New(out.Octets(), out.Tell()))
+	trees = trees[:0]
+	for context := 0; context < 8; context++ {
+		trees = append(trees, NewBitTree(3))
+	}
+	context = 0
+	for i, expected := range symbols {
+		symbol, err := decoder.DecodeSymbol(trees[context])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if symbol != expected {
+			t.Fatalf("Index %v: expected %v but got %v", i, expected, symbol)
+		}
+		context = symbol
+	}
+}
+
+func TestRandomBitsWithCarries(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	bits := make([]uint32, 20000)
+	contexts := make([]int, len(bits))
+	for i := range bits {
+		contexts[i] = random.Intn(4)
+		// Context 0 is almost always one, which produces long runs of 0xff octets and carries
+		if contexts[i] == 0 || random.Intn(2) == 0 {
+			bits[i] = 1
+		}
+	}
+
+	out := outbitstream.New(8192)
+	encoder := NewEncoder(out)
+	probabilities := NewProbabilities(4)
+	for i, bit := range bits {
+		if err := encoder.EncodeBit(&probabilities[contexts[i]], bit); err != nil {
+			t.Fatal(err)
+		}
+	}
+	encoder.Close()
+
+	decoder, _ := NewDecoder(inbitstream.New(out.Octets(), out.Tell()))
+	probabilities = NewProbabilities(4)
+	for i, expected := range bits {
+		bit, err := decoder.DecodeBit(&probabilities[contexts[i]])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bit != expected {
+			t.Fatalf("Index %v: expected %v but got %v", i, expected, bit)
+		}
+	}
+}
+
+func TestSymbolTooLarge(t *testing.T) {
+	encoder := NewEncoder(outbitstream.New(64))
+	if encoder.EncodeSymbol(NewBitTree(2), 4) == nil {
+		t.Errorf("Expected error")
+	}
+}
+
+func TestTruncated(t *testing.T) {
+	_, err := NewDecoder(inbitstream.New([]byte{0, 1}, 16))
+	if err == nil {
+		t.Errorf("Expected error")
+	}
+}
@@ -0,0 +1,156 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package rans ...
+package rans
+
+import (
+	"fmt"
+
+	"github.com/piot/brook-go/src/instream"
+	"github.com/piot/brook-go/src/outstream"
+)
+
+// lowerBound : The state is kept in lowerBound..(lowerBound<<8)-1 between symbols
+const lowerBound = 1 << 23
+
+// put codes one symbol into the state, appending renormalization octets to reversed
+func (t *Table) put(state uint32, symbol byte, reversed []byte) (uint32, []byte) {
+	e := &t.encode[symbol]
+	for state >= e.maxState {
+		reversed = append(reversed, byte(state))
+		state >>= 8
+	}
+	quotient := uint32((uint64(state) * uint64(e.reciprocal)) >> e.reciprocalShift)
+	return state + e.bias + quotient*e.complementFrequency, reversed
+}
+
+// Encode : Codes data with the table. Every octet in data must have a frequency in the table.
+// Even and odd octets use separate states, which lets the processor work on both at the same time
+func (t *Table) Encode(data []byte) ([]byte, error) {
+	for i, symbol := range data {
+		if t.frequencies[symbol] == 0 {
+			return nil, fmt.Errorf("rans: octet %v at index %v has no frequency in the table", symbol, i)
+		}
+	}
+	// rANS works like a stack, so symbols are coded in reverse and the output is reversed at the end
+	reversed := make([]byte, 0, len(data)+8)
+	even := uint32(lowerBound)
+	odd := uint32(lowerBound)
+	for i := len(data) - 1; i >= 0; i-- {
+		if i&1 == 0 {
+			even, reversed = t.put(even, data[i], reversed)
+		} else {
+			odd, reversed = t.put(odd, data[i], reversed)
+		}
+	}
+	reversed = append(reversed, byte(odd), byte(odd>>8), byte(odd>>16), byte(odd>>24))
+	reversed = append(reversed, byte(even), byte(even>>8), byte(even>>16), byte(even>>24))
+
+	payload := make([]byte, len(reversed))
+	for i, octet := range reversed {
+		payload[len(reversed)-1-i] = octet
+	}
+	return payload, nil
+}
+
+func readState(payload []byte) uint32 {
+	return uint32(payload[0])<<24 | uint32(payload[1])<<16 | uint32(payload[2])<<8 | uint32(payload[3])
+}
+
+// Decode : Decodes length octets from a payload created by Encode
+func (t *Table) Decode(payload []byte, length int) ([]byte, error) {
+	if len(payload) < 8 {
+		return nil, fmt.Errorf("rans: payload is too short")
+	}
+	states := [2]uint32{readState(payload), readState(payload[4:])}
+	position := 8
+	data := make([]byte, length)
+	for i := range data {
+		state := states[i&1]
+		slot := state & (scale - 1)
+		symbol := t.slots[slot]
+		data[i] = symbol
+		state = t.frequencies[symbol]*(state>>ScaleBits) + slot - t.starts[symbol]
+		for state < lowerBound {
+			if position >= len(payload) {
+				return nil, fmt.Errorf("rans: payload ended after %v of %v octets", i+1, length)
+			}
+			state = state<<8 | uint32(payload[position])
+			position++
+		}
+		states[i&1] = state
+	}
+	if states[0] != lowerBound || states[1] != lowerBound || position != len(payload) {
+		return nil, fmt.Errorf("rans: payload does not match the table")
+	}
+	return data, nil
+}
+
+// Write : Writes data with a header containing the length, the frequency table and the payload size
+func Write(out *outstream.OutStream, data []byte) error {
+	lengthErr := out.WriteUint32(uint32(len(data)))
+	if lengthErr != nil || len(data) == 0 {
+		return lengthErr
+	}
+	t, tableErr := BuildTable(data)
+	if tableErr != nil {
+		return tableErr
+	}
+	writeTableErr := WriteTable(out, t)
+	if writeTableErr != nil {
+		return writeTableErr
+	}
+	payload, encodeErr := t.Encode(data)
+	if encodeErr != nil {
+		return encodeErr
+	}
+	return out.WriteBlob(payload, 4)
+}
+
+// Read : Reads data written by Write. Fails if the data is longer than maxLength octets
+func Read(in *instream.InStream, maxLength int) ([]byte, error) {
+	length, lengthErr := in.ReadUint32()
+	if lengthErr != nil {
+		return nil, lengthErr
+	}
+	if uint64(length) > uint64(maxLength) {
+		return nil, &instream.OverflowError{Value: int(length), Max: maxLength, Tell: in.Tell()}
+	}
+	if length == 0 {
+		return []byte{}, nil
+	}
+	t, tableErr := ReadTable(in)
+	if tableErr != nil {
+		return nil, tableErr
+	}
+	// An octet costs at most ScaleBits bits, plus the final state
+	payload, payloadErr := in.ReadBlob(4, int(length)*ScaleBits/8+8)
+	if payloadErr != nil {
+		return nil, payloadErr
+	}
+	return t.Decode(payload, int(length))
+}
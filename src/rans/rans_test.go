@@ -0,0 +1,194 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package rans
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/piot/brook-go/src/huffman"
+	"github.com/piot/brook-go/src/inbitstream"
+	"github.com/piot/brook-go/src/instream"
+	"github.com/piot/brook-go/src/outbitstream"
+	"github.com/piot/brook-go/src/outstream"
+)
+
+// replayData returns skewed octets, similar to a replay of mostly small deltas
+func replayData(length int) []byte {
+	random := rand.New(rand.NewSource(11))
+	data := make([]byte, length)
+	for i := range data {
+		v := random.ExpFloat64() * 3
+		if v > 255 {
+			v = 255
+		}
+		data[i] = byte(v)
+	}
+	return data
+}
+
+func roundTrip(t *testing.T, data []byte) int {
+	out := outstream.New()
+	writeErr := Write(out, data)
+	if writeErr != nil {
+		t.Fatal(writeErr)
+	}
+	octets := out.Octets()
+	in := instream.New(octets)
+	decoded, readErr := Read(in, len(data))
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	if !bytes.Equal(decoded, data) {
+		t.Fatalf("Decoded data differs")
+	}
+	if !in.IsEOF() {
+		t.Errorf("Expected to read all octets")
+	}
+	return len(octets)
+}
+
+func TestSkewedData(t *testing.T) {
+	data := replayData(64 * 1024)
+	size := roundTrip(t, data)
+	if size > len(data)/2 {
+		t.Errorf("Expected at least 50%% savings but got %v octets for %v", size, len(data))
+	}
+}
+
+func TestEdgeCases(t *testing.T) {
+	roundTrip(t, []byte{})
+	roundTrip(t, []byte{42})
+	roundTrip(t, bytes.Repeat([]byte{7}, 1000))
+
+	all := make([]byte, 256*3)
+	for i := range all {
+		all[i] = byte(i)
+	}
+	roundTrip(t, all)
+
+	// One frequent octet and many rare ones, so the rare ones need the minimum frequency
+	rare := bytes.Repeat([]byte{0}, 100000)
+	for i := 0; i < 255; i++ {
+		rare[i*7] = byte(i + 1)
+	}
+	roundTrip(t, rare)
+}
+
+func TestTableNormalized(t *testing.T) {
+	table, err := BuildTable([]byte("aaaaaaab"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.Frequency('a')+table.Frequency('b') != 1<<ScaleBits || table.Frequency('b') == 0 {
+		t.Errorf("Unexpected frequencies %v %v", table.Frequency('a'), table.Frequency('b'))
+	}
+	if _, encodeErr := table.Encode([]byte("c")); encodeErr == nil {
+		t.Errorf("Expected error for octet without frequency")
+	}
+}
+
+func TestCorruptPayload(t *testing.T) {
+	data := replayData(1000)
+	table, _ := BuildTable(data)
+	payload, _ := table.Encode(data)
+	if _, err := table.Decode(payload[:len(payload)-3], len(data)); err == nil {
+		t.Errorf("Expected error for truncated payload")
+	}
+	if _, err := Read(instream.New([]byte{0, 0, 1, 0}), 100); err == nil {
+		t.Errorf("Expected error for too long data")
+	}
+}
+
+const benchmarkLength = 256 * 1024
+
+func BenchmarkRansEncode(b *testing.B) {
+	data := replayData(benchmarkLength)
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Write(outstream.New(), data)
+	}
+}
+
+func BenchmarkRansDecode(b *testing.B) {
+	data := replayData(benchmarkLength)
+	out := outstream.New()
+	Write(out, data)
+	octets := out.Octets()
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Read(instream.New(octets), len(data))
+	}
+}
+
+func huffmanWrite(data []byte) (*outbitstream.OutBitStreamImpl, error) {
+	symbols := make([]uint32, len(data))
+	for i, octet := range data {
+		symbols[i] = uint32(octet)
+	}
+	frequencies, _ := huffman.CountFrequencies(symbols, 256)
+	table, buildErr := huffman.Build(frequencies, 15)
+	if buildErr != nil {
+		return nil, buildErr
+	}
+	out := outbitstream.New(len(data)*2 + 1024)
+	huffman.WriteTable(out, table)
+	for _, symbol := range symbols {
+		writeErr := table.Write(out, symbol)
+		if writeErr != nil {
+			return nil, writeErr
+		}
+	}
+	return out, nil
+}
+
+func BenchmarkHuffmanEncode(b *testing.B) {
+	data := replayData(benchmarkLength)
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		huffmanWrite(data)
+	}
+}
+
+func BenchmarkHuffmanDecode(b *testing.B) {
+	data := replayData(benchmarkLength)
+	out, _ := huffmanWrite(data)
+	octets := out.Octets()
+	bitCount := out.Tell()
+	symbols := make([]uint32, len(data))
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		in := inbitstream.New(octets, bitCount)
+		table, _ := huffman.ReadTable(in)
+		table.ReadSymbols(in, symbols)
+	}
+}
@@ -0,0 +1,210 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package rans is a byte oriented rANS entropy coder with static frequency tables.
+// It codes whole octets at a time, which makes it much faster than bit by bit coders.
+package rans
+
+import (
+	"fmt"
+
+	"github.com/piot/brook-go/src/instream"
+	"github.com/piot/brook-go/src/outstream"
+)
+
+const (
+	// ScaleBits : Frequencies are normalized to sum up to 1 << ScaleBits
+	ScaleBits   = 12
+	scale       = 1 << ScaleBits
+	symbolCount = 256
+)
+
+// encodeSymbol holds the precomputed values that replace the division when encoding a symbol
+type encodeSymbol struct {
+	maxState            uint32
+	reciprocal          uint32
+	reciprocalShift     uint32
+	bias                uint32
+	complementFrequency uint32
+}
+
+func newEncodeSymbol(start uint32, frequency uint32) encodeSymbol {
+	e := encodeSymbol{
+		maxState:            ((lowerBound >> ScaleBits) << 8) * frequency,
+		complementFrequency: scale - frequency,
+	}
+	if frequency < 2 {
+		// state/1 is state itself, which the reciprocal can not express
+		e.reciprocal = 0xffffffff
+		e.reciprocalShift = 32
+		e.bias = start + scale - 1
+		return e
+	}
+	shift := uint32(0)
+	for frequency > 1<<shift {
+		shift++
+	}
+	e.reciprocal = uint32(((uint64(1) << (shift + 31)) + uint64(frequency) - 1) / uint64(frequency))
+	e.reciprocalShift = shift - 1 + 32
+	e.bias = start
+	return e
+}
+
+// Table : Normalized symbol frequencies with the lookup tables for encoding and decoding
+type Table struct {
+	frequencies [symbolCount]uint32
+	starts      [symbolCount]uint32
+	encode      [symbolCount]encodeSymbol
+	// slots maps every position in 0..scale-1 to its symbol, for decoding
+	slots [scale]uint8
+}
+
+// NewTable : Creates a table from frequencies that sum up to 1 << ScaleBits
+func NewTable(frequencies [symbolCount]uint32) (*Table, error) {
+	t := &Table{frequencies: frequencies}
+	start := uint32(0)
+	for symbol, frequency := range frequencies {
+		t.starts[symbol] = start
+		t.encode[symbol] = newEncodeSymbol(start, frequency)
+		if start+frequency > scale {
+			return nil, fmt.Errorf("rans: frequencies sum up to more than %v", scale)
+		}
+		for i := uint32(0); i < frequency; i++ {
+			t.slots[start+i] = uint8(symbol)
+		}
+		start += frequency
+	}
+	if start != scale {
+		return nil, fmt.Errorf("rans: frequencies sum up to %v, expected %v", start, scale)
+	}
+	return t, nil
+}
+
+// BuildTable : Counts the octets in data and normalizes the counts. Every octet that occurs gets a frequency of at least one
+func BuildTable(data []byte) (*Table, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("rans: can not build a table from empty data")
+	}
+	var counts [symbolCount]uint64
+	for _, octet := range data {
+		counts[octet]++
+	}
+
+	var frequencies [symbolCount]uint32
+	sum := 0
+	for symbol, count := range counts {
+		if count == 0 {
+			continue
+		}
+		frequency := uint32(count * scale / uint64(len(data)))
+		if frequency == 0 {
+			frequency = 1
+		}
+		frequencies[symbol] = frequency
+		sum += int(frequency)
+	}
+	// Rounding leaves the sum a bit off, adjust the most frequent symbols where it matters the least
+	for sum != scale {
+		largest := 0
+		for symbol, frequency := range frequencies {
+			if frequency > frequencies[largest] {
+				largest = symbol
+			}
+		}
+		if sum > scale {
+			// Take from the largest symbol that can spare it
+			best := -1
+			for symbol, frequency := range frequencies {
+				if frequency > 1 && (best < 0 || frequency > frequencies[best]) {
+					best = symbol
+				}
+			}
+			frequencies[best]--
+			sum--
+		} else {
+			frequencies[largest]++
+			sum++
+		}
+	}
+	return NewTable(frequencies)
+}
+
+// Frequency : Normalized frequency of the octet
+func (t *Table) Frequency(octet byte) uint32 {
+	return t.frequencies[octet]
+}
+
+// WriteTable : Writes the used octets and their frequencies
+func WriteTable(out *outstream.OutStream, t *Table) error {
+	usedCount := 0
+	for _, frequency := range t.frequencies {
+		if frequency > 0 {
+			usedCount++
+		}
+	}
+	countErr := out.WriteUint8(uint8(usedCount - 1))
+	if countErr != nil {
+		return countErr
+	}
+	for symbol, frequency := range t.frequencies {
+		if frequency == 0 {
+			continue
+		}
+		symbolErr := out.WriteUint8(uint8(symbol))
+		if symbolErr != nil {
+			return symbolErr
+		}
+		frequencyErr := out.WriteUint16(uint16(frequency - 1))
+		if frequencyErr != nil {
+			return frequencyErr
+		}
+	}
+	return nil
+}
+
+// ReadTable : Reads a table written by WriteTable
+func ReadTable(in *instream.InStream) (*Table, error) {
+	usedCountMinusOne, countErr := in.ReadUint8()
+	if countErr != nil {
+		return nil, countErr
+	}
+	var frequencies [symbolCount]uint32
+	for i := 0; i <= int(usedCountMinusOne); i++ {
+		symbol, symbolErr := in.ReadUint8()
+		if symbolErr != nil {
+			return nil, symbolErr
+		}
+		frequencyMinusOne, frequencyErr := in.ReadUint16()
+		if frequencyErr != nil {
+			return nil, frequencyErr
+		}
+		if frequencies[symbol] != 0 {
+			return nil, fmt.Errorf("rans: octet %v is listed twice in the table", symbol)
+		}
+		frequencies[symbol] = uint32(frequencyMinusOne) + 1
+	}
+	return NewTable(frequencies)
+}
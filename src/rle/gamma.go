@@ -0,0 +1,83 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package rle ...
+package rle
+
+import (
+	"fmt"
+	"math/bits"
+
+	"github.com/piot/brook-go/src/inbitstream"
+	"github.com/piot/brook-go/src/outbitstream"
+)
+
+// gammaBitCount : Number of bits used by the Elias gamma code for v, which must be at least one
+func gammaBitCount(v uint32) uint {
+	return uint(bits.Len32(v))*2 - 1
+}
+
+// writeGamma : Writes v (at least one) as Elias gamma, the bit length minus one as zero bits followed by v itself.
+// The zeros and the leading one bit are written one bit per call, to match the reads in readGamma on debug streams
+func writeGamma(targetStream outbitstream.OutBitStream, v uint32) error {
+	bitCount := uint(bits.Len32(v))
+	for i := uint(1); i < bitCount; i++ {
+		zeroErr := targetStream.WriteBits(0, 1)
+		if zeroErr != nil {
+			return zeroErr
+		}
+	}
+	oneErr := targetStream.WriteBits(1, 1)
+	if oneErr != nil || bitCount == 1 {
+		return oneErr
+	}
+	return targetStream.WriteBits(v&(1<<(bitCount-1)-1), bitCount-1)
+}
+
+func readGamma(sourceStream inbitstream.InBitStream) (uint32, error) {
+	zeroCount := uint(0)
+	for {
+		bit, readErr := sourceStream.ReadBits(1)
+		if readErr != nil {
+			return 0, readErr
+		}
+		if bit != 0 {
+			break
+		}
+		zeroCount++
+		if zeroCount > 31 {
+			return 0, fmt.Errorf("rle: run length has more than 32 bits")
+		}
+	}
+	if zeroCount == 0 {
+		return 1, nil
+	}
+	lowerBits, lowerErr := sourceStream.ReadBits(zeroCount)
+	if lowerErr != nil {
+		return 0, lowerErr
+	}
+	return 1<<zeroCount | lowerBits, nil
+}
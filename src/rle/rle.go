@@ -0,0 +1,195 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package rle run-length encodes long bit arrays that are mostly one value, e.g. visibility masks
+// and fog-of-war grids. Arrays that would grow from run-length encoding are written as raw bits.
+package rle
+
+import (
+	"fmt"
+
+	"github.com/piot/brook-go/src/bitset"
+	"github.com/piot/brook-go/src/inbitstream"
+	"github.com/piot/brook-go/src/outbitstream"
+)
+
+// runs : Lengths of the runs of equal bits. The first run has the value of the first bit and the
+// values then alternate
+func runs(b *bitset.Bitset) []uint32 {
+	var lengths []uint32
+	length := b.Len()
+	start := uint(0)
+	for start < length {
+		value := b.Test(start)
+		end := start + 1
+		for end < length && b.Test(end) == value {
+			end++
+		}
+		lengths = append(lengths, uint32(end-start))
+		start = end
+	}
+	return lengths
+}
+
+// runBitCount : Number of bits for the first value and the runs
+func runBitCount(lengths []uint32) uint {
+	bitCount := uint(1)
+	for _, runLength := range lengths {
+		bitCount += gammaBitCount(runLength)
+	}
+	return bitCount
+}
+
+// BitCount : Number of bits Write uses for the bit array, including the mode bit
+func BitCount(b *bitset.Bitset) uint {
+	return 1 + encodedBitCount(b.Len(), runBitCount(runs(b)))
+}
+
+func encodedBitCount(length uint, runBits uint) uint {
+	if runBits < length {
+		return runBits
+	}
+	return length
+}
+
+// Write : Writes a mode bit followed by either the runs or, when that is smaller, the raw bits
+func Write(targetStream outbitstream.OutBitStream, b *bitset.Bitset) error {
+	lengths := runs(b)
+	useRuns := runBitCount(lengths) < b.Len()
+	modeErr := outbitstream.WriteBool(targetStream, useRuns)
+	if modeErr != nil {
+		return modeErr
+	}
+	if useRuns {
+		return writeRuns(targetStream, b, lengths)
+	}
+	return writeRaw(targetStream, b)
+}
+
+func writeRuns(targetStream outbitstream.OutBitStream, b *bitset.Bitset, lengths []uint32) error {
+	firstErr := outbitstream.WriteBool(targetStream, b.Test(0))
+	if firstErr != nil {
+		return firstErr
+	}
+	for _, runLength := range lengths {
+		runErr := writeGamma(targetStream, runLength)
+		if runErr != nil {
+			return runErr
+		}
+	}
+	return nil
+}
+
+func writeRaw(targetStream outbitstream.OutBitStream, b *bitset.Bitset) error {
+	length := b.Len()
+	for start := uint(0); start < length; start += 32 {
+		count := length - start
+		if count > 32 {
+			count = 32
+		}
+		word := uint32(0)
+		for i := uint(0); i < count; i++ {
+			word <<= 1
+			if b.Test(start + i) {
+				word |= 1
+			}
+		}
+		writeErr := targetStream.WriteBits(word, count)
+		if writeErr != nil {
+			return writeErr
+		}
+	}
+	return nil
+}
+
+// Read : Reads a bit array with the specified length written by Write
+func Read(sourceStream inbitstream.InBitStream, length uint) (*bitset.Bitset, error) {
+	useRuns, modeErr := inbitstream.ReadBool(sourceStream)
+	if modeErr != nil {
+		return nil, modeErr
+	}
+	b := bitset.New(length)
+	var readErr error
+	if useRuns {
+		readErr = readRuns(sourceStream, b)
+	} else {
+		readErr = readRaw(sourceStream, b)
+	}
+	if readErr != nil {
+		return nil, readErr
+	}
+	return b, nil
+}
+
+func setRange(b *bitset.Bitset, start uint, end uint) {
+	for i := start; i < end; i++ {
+		b.Set(i)
+	}
+}
+
+func readRuns(sourceStream inbitstream.InBitStream, b *bitset.Bitset) error {
+	value, firstErr := inbitstream.ReadBool(sourceStream)
+	if firstErr != nil {
+		return firstErr
+	}
+	length := b.Len()
+	position := uint(0)
+	for position < length {
+		runLength, runErr := readGamma(sourceStream)
+		if runErr != nil {
+			return runErr
+		}
+		if uint(runLength) > length-position {
+			return fmt.Errorf("rle: run of %v bits exceeds the remaining %v bits", runLength, length-position)
+		}
+		if value {
+			setRange(b, position, position+uint(runLength))
+		}
+		position += uint(runLength)
+		value = !value
+	}
+	return nil
+}
+
+func readRaw(sourceStream inbitstream.InBitStream, b *bitset.Bitset) error {
+	length := b.Len()
+	for start := uint(0); start < length; start += 32 {
+		count := length - start
+		if count > 32 {
+			count = 32
+		}
+		word, readErr := sourceStream.ReadBits(count)
+		if readErr != nil {
+			return readErr
+		}
+		for i := uint(0); i < count; i++ {
+			if word&(1<<(count-1-i)) != 0 {
+				b.Set(start + i)
+			}
+		}
+	}
+	return nil
+}
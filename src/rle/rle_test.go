@@ -0,0 +1,164 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package rle
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/piot/brook-go/src/bitset"
+	"github.com/piot/brook-go/src/inbitstream"
+	"github.com/piot/brook-go/src/outbitstream"
+)
+
+func fogOfWar(width uint, height uint) *bitset.Bitset {
+	b := bitset.New(width * height)
+	// A revealed circle in the middle of an otherwise hidden map
+	for y := uint(0); y < height; y++ {
+		for x := uint(0); x < width; x++ {
+			dx := int(x) - int(width/2)
+			dy := int(y) - int(height/2)
+			if dx*dx+dy*dy < 100 {
+				b.Set(y*width + x)
+			}
+		}
+	}
+	return b
+}
+
+func noise(length uint) *bitset.Bitset {
+	random := rand.New(rand.NewSource(47))
+	b := bitset.New(length)
+	for i := uint(0); i < length; i++ {
+		if random.Intn(2) == 0 {
+			b.Set(i)
+		}
+	}
+	return b
+}
+
+func roundTrip(t *testing.T, b *bitset.Bitset) uint {
+	out := outbitstream.New(int(b.Len()/8) + 64)
+	err := Write(out, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Tell() != BitCount(b) {
+		t.Errorf("Expected %v bits written but got %v", BitCount(b), out.Tell())
+	}
+	in := inbitstream.New(out.Octets(), out.Tell())
+	readSet, readErr := Read(in, b.Len())
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	for i := uint(0); i < b.Len(); i++ {
+		if readSet.Test(i) != b.Test(i) {
+			t.Fatalf("Bit %v: expected %v", i, b.Test(i))
+		}
+	}
+	if !in.IsEOF() {
+		t.Errorf("Expected all bits to be read")
+	}
+	return out.Tell()
+}
+
+func TestGamma(t *testing.T) {
+	out := outbitstream.New(16)
+	for _, v := range []uint32{1, 5} {
+		if err := writeGamma(out, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 1 is a single one bit, 5 is 00 101
+	in := inbitstream.New(out.Octets(), out.Tell())
+	v, _ := in.ReadBits(out.Tell())
+	if out.Tell() != 6 || v != 0x25 {
+		t.Errorf("Expected 100101 but got %b (%v bits)", v, out.Tell())
+	}
+}
+
+func TestSparseMask(t *testing.T) {
+	b := fogOfWar(64, 64)
+	bitCount := roundTrip(t, b)
+	if bitCount*10 > b.Len() {
+		t.Errorf("Expected run-length encoding to be at least ten times smaller than %v bits but got %v", b.Len(), bitCount)
+	}
+}
+
+func TestFallbackToRaw(t *testing.T) {
+	b := noise(1000)
+	bitCount := roundTrip(t, b)
+	if bitCount != b.Len()+1 {
+		t.Errorf("Expected raw bits plus mode bit (%v) but got %v", b.Len()+1, bitCount)
+	}
+}
+
+func TestEdgeCases(t *testing.T) {
+	roundTrip(t, bitset.New(0))
+	roundTrip(t, bitset.New(1))
+	allSet := bitset.New(100000)
+	for i := uint(0); i < allSet.Len(); i++ {
+		allSet.Set(i)
+	}
+	if roundTrip(t, allSet) > 40 {
+		t.Errorf("Expected a single run to be small")
+	}
+	single := bitset.New(4097)
+	single.Set(4096)
+	roundTrip(t, single)
+}
+
+func TestRoundTripOverDebugStream(t *testing.T) {
+	for _, b := range []*bitset.Bitset{fogOfWar(32, 32), noise(100)} {
+		out := outbitstream.NewDebugStream(outbitstream.New(4096))
+		if err := Write(out, b); err != nil {
+			t.Fatal(err)
+		}
+		in := inbitstream.NewDebugStream(inbitstream.New(out.Octets(), out.Tell()))
+		readSet, readErr := Read(in, b.Len())
+		if readErr != nil {
+			t.Fatal(readErr)
+		}
+		for i := uint(0); i < b.Len(); i++ {
+			if readSet.Test(i) != b.Test(i) {
+				t.Fatalf("Bit %v: expected %v", i, b.Test(i))
+			}
+		}
+	}
+}
+
+func TestRunLongerThanLength(t *testing.T) {
+	out := outbitstream.New(16)
+	outbitstream.WriteBool(out, true)
+	outbitstream.WriteBool(out, false)
+	writeGamma(out, 20)
+	in := inbitstream.New(out.Octets(), out.Tell())
+	_, err := Read(in, 10)
+	if err == nil {
+		t.Errorf("Expected error for too long run")
+	}
+}
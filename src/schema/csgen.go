@@ -0,0 +1,527 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package schema ...
+package schema
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/piot/brook-go/src/brookstruct"
+	"github.com/piot/brook-go/src/codegen"
+)
+
+// CSharpFile : Options for the generated C# file
+type CSharpFile struct {
+	// Namespace : C# namespace, defaults to the schema package name with an upper case first letter
+	Namespace string
+	// Generator : Name of the generating command, used in the header comment
+	Generator string
+}
+
+type csWriter struct {
+	buffer      strings.Builder
+	indent      int
+	helperClass string
+	helpers     map[string]bool
+	schema      *Schema
+}
+
+func (w *csWriter) line(format string, args ...interface{}) {
+	if format != "" {
+		w.buffer.WriteString(strings.Repeat("    ", w.indent))
+		fmt.Fprintf(&w.buffer, format, args...)
+	}
+	w.buffer.WriteString("\n")
+}
+
+// open starts a block, an empty format starts an anonymous scope
+func (w *csWriter) open(format string, args ...interface{}) {
+	if format != "" {
+		w.line(format, args...)
+	}
+	w.line("{")
+	w.indent++
+}
+
+func (w *csWriter) close() {
+	w.indent--
+	w.line("}")
+}
+
+func (w *csWriter) helper(name string) string {
+	w.helpers[name] = true
+	return w.helperClass + "." + name
+}
+
+func csScalarName(kind brookstruct.ScalarKind) string {
+	switch kind {
+	case brookstruct.KindBool:
+		return "bool"
+	case brookstruct.KindUint8:
+		return "byte"
+	case brookstruct.KindUint16:
+		return "ushort"
+	case brookstruct.KindUint32:
+		return "uint"
+	case brookstruct.KindUint64, brookstruct.KindUint:
+		return "ulong"
+	case brookstruct.KindInt8:
+		return "sbyte"
+	case brookstruct.KindInt16:
+		return "short"
+	case brookstruct.KindInt32:
+		return "int"
+	case brookstruct.KindInt64, brookstruct.KindInt:
+		return "long"
+	case brookstruct.KindFloat32:
+		return "float"
+	case brookstruct.KindFloat64:
+		return "double"
+	case brookstruct.KindString:
+		return "string"
+	}
+	panic(fmt.Sprintf("schema: unknown scalar kind %v", kind))
+}
+
+func (w *csWriter) typeName(t *codegen.Type) string {
+	switch t.Kind {
+	case codegen.TypeScalar:
+		if w.schema.Enum(t.GoName) != nil {
+			return t.GoName
+		}
+		return csScalarName(t.Scalar)
+	case codegen.TypeStruct:
+		return t.GoName
+	case codegen.TypeSlice:
+		return "List<" + w.typeName(t.Elem) + ">"
+	case codegen.TypeArray:
+		return w.typeName(t.Elem) + "[]"
+	case codegen.TypePointer:
+		if t.Elem.Kind == codegen.TypeScalar && t.Elem.Scalar != brookstruct.KindString {
+			return w.typeName(t.Elem) + "?"
+		}
+		return w.typeName(t.Elem)
+	}
+	panic(fmt.Sprintf("schema: unknown type kind %v", t.Kind))
+}
+
+// initializer returns the field initializer, so non optional fields are never null
+func (w *csWriter) initializer(t *codegen.Type) string {
+	switch t.Kind {
+	case codegen.TypeScalar:
+		if t.Scalar == brookstruct.KindString {
+			return " = \"\""
+		}
+	case codegen.TypeStruct:
+		return fmt.Sprintf(" = new %v()", t.GoName)
+	case codegen.TypeSlice:
+		return fmt.Sprintf(" = new %v()", w.typeName(t))
+	case codegen.TypeArray:
+		elemInitializer := w.initializer(t.Elem)
+		if elemInitializer == "" {
+			return fmt.Sprintf(" = new %v[%d]", w.typeName(t.Elem), t.ArrayLength)
+		}
+		elems := make([]string, t.ArrayLength)
+		for index := range elems {
+			elems[index] = strings.TrimPrefix(elemInitializer, " = ")
+		}
+		return fmt.Sprintf(" = new %v[] { %v }", w.typeName(t.Elem), strings.Join(elems, ", "))
+	}
+	return ""
+}
+
+func csFloat(f float64) string {
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eE") {
+		s += ".0"
+	}
+	return s
+}
+
+func (w *csWriter) writeScalar(t *codegen.Type, expr string) {
+	plan := t.Plan
+	switch plan.Method {
+	case brookstruct.MethodBool:
+		w.line("stream.WriteBits(%v ? 1u : 0u, 1);", expr)
+	case brookstruct.MethodBits:
+		w.line("%v(stream, (ulong)%v, %d);", w.helper("WriteUnsignedBits"), expr, plan.Bits)
+	case brookstruct.MethodSignedBits:
+		w.line("%v(stream, (long)%v, %d);", w.helper("WriteSignedBits"), expr, plan.Bits)
+	case brookstruct.MethodRange:
+		w.line("%v(stream, (long)%v, %d, %d);", w.helper("WriteRange"), expr, plan.Min, plan.Max)
+	case brookstruct.MethodQuant:
+		w.line("%v(stream, %v, %v, %v, %d);", w.helper("WriteQuantized"), expr, csFloat(plan.QuantMin), csFloat(plan.QuantMax), plan.Bits)
+	case brookstruct.MethodUint8:
+		w.line("stream.WriteUint8((byte)%v);", expr)
+	case brookstruct.MethodUint16:
+		w.line("stream.WriteUint16((ushort)%v);", expr)
+	case brookstruct.MethodUint32:
+		w.line("stream.WriteUint32((uint)%v);", expr)
+	case brookstruct.MethodUint64:
+		w.line("stream.WriteUint64((ulong)%v);", expr)
+	case brookstruct.MethodInt16:
+		w.line("%v(stream, (long)%v, 16);", w.helper("WriteSignedBits"), expr)
+	case brookstruct.MethodFloat32:
+		w.line("stream.WriteUint32((uint)BitConverter.SingleToInt32Bits(%v));", expr)
+	case brookstruct.MethodFloat64:
+		w.line("stream.WriteUint64((ulong)BitConverter.DoubleToInt64Bits(%v));", expr)
+	case brookstruct.MethodString:
+		w.line("%v(stream, %v, %d, %d);", w.helper("WriteString"), expr, plan.Bits, plan.MaxLength)
+	default:
+		panic(fmt.Sprintf("schema: unknown method %v", plan.Method))
+	}
+}
+
+func (w *csWriter) readScalar(t *codegen.Type) string {
+	plan := t.Plan
+	name := w.typeName(t)
+	switch plan.Method {
+	case brookstruct.MethodBool:
+		return "stream.ReadBits(1) != 0"
+	case brookstruct.MethodBits:
+		return fmt.Sprintf("(%v)stream.ReadBits(%d)", name, plan.Bits)
+	case brookstruct.MethodSignedBits:
+		return fmt.Sprintf("(%v)stream.ReadSignedBits(%d)", name, plan.Bits)
+	case brookstruct.MethodRange:
+		return fmt.Sprintf("(%v)%v(stream, %d, %d)", name, w.helper("ReadRange"), plan.Min, plan.Max)
+	case brookstruct.MethodQuant:
+		return fmt.Sprintf("(%v)%v(stream, %v, %v, %d)", name, w.helper("ReadQuantized"), csFloat(plan.QuantMin), csFloat(plan.QuantMax), plan.Bits)
+	case brookstruct.MethodUint8:
+		return fmt.Sprintf("(%v)stream.ReadUint8()", name)
+	case brookstruct.MethodUint16:
+		return fmt.Sprintf("(%v)stream.ReadUint16()", name)
+	case brookstruct.MethodUint32:
+		return fmt.Sprintf("(%v)stream.ReadUint32()", name)
+	case brookstruct.MethodUint64:
+		return fmt.Sprintf("(%v)stream.ReadUint64()", name)
+	case brookstruct.MethodInt16:
+		return fmt.Sprintf("(%v)stream.ReadInt16()", name)
+	case brookstruct.MethodFloat32:
+		return fmt.Sprintf("(%v)BitConverter.Int32BitsToSingle((int)stream.ReadUint32())", name)
+	case brookstruct.MethodFloat64:
+		return fmt.Sprintf("(%v)BitConverter.Int64BitsToDouble((long)stream.ReadUint64())", name)
+	case brookstruct.MethodString:
+		return fmt.Sprintf("%v(stream, %d, %d)", w.helper("ReadString"), plan.Bits, plan.MaxLength)
+	}
+	panic(fmt.Sprintf("schema: unknown method %v", plan.Method))
+}
+
+func (w *csWriter) writeValue(t *codegen.Type, expr string, depth int) {
+	switch t.Kind {
+	case codegen.TypeScalar:
+		w.writeScalar(t, expr)
+	case codegen.TypeStruct:
+		w.line("%vSerializer.Serialize(stream, %v);", t.GoName, expr)
+	case codegen.TypePointer:
+		w.line("stream.WriteBits(%v != null ? 1u : 0u, 1);", expr)
+		w.open("if (%v != null)", expr)
+		valueExpr := expr
+		if strings.HasSuffix(w.typeName(t), "?") {
+			valueExpr += ".Value"
+		}
+		w.writeValue(t.Elem, valueExpr, depth)
+		w.close()
+	case codegen.TypeSlice:
+		item := fmt.Sprintf("item%d", depth)
+		w.line("%v(stream, %v.Count, %d, %d);", w.helper("WriteLength"), expr, t.LengthBitCount, t.MaxLength)
+		w.open("foreach (var %v in %v)", item, expr)
+		w.writeValue(t.Elem, item, depth+1)
+		w.close()
+	case codegen.TypeArray:
+		index := fmt.Sprintf("i%d", depth)
+		w.line("%v(%v.Length, %d);", w.helper("CheckArrayLength"), expr, t.ArrayLength)
+		w.open("for (var %v = 0; %v < %d; %v++)", index, index, t.ArrayLength, index)
+		w.writeValue(t.Elem, fmt.Sprintf("%v[%v]", expr, index), depth+1)
+		w.close()
+	}
+}
+
+func (w *csWriter) readValue(t *codegen.Type, target string, depth int) {
+	switch t.Kind {
+	case codegen.TypeScalar:
+		w.line("%v = %v;", target, w.readScalar(t))
+	case codegen.TypeStruct:
+		w.line("%v = %vSerializer.Deserialize(stream);", target, t.GoName)
+	case codegen.TypePointer:
+		w.open("if (stream.ReadBits(1) != 0)")
+		w.readValue(t.Elem, target, depth)
+		w.close()
+		w.open("else")
+		w.line("%v = null;", target)
+		w.close()
+	case codegen.TypeSlice:
+		count := fmt.Sprintf("count%d", depth)
+		list := fmt.Sprintf("list%d", depth)
+		item := fmt.Sprintf("item%d", depth)
+		index := fmt.Sprintf("i%d", depth)
+		w.open("")
+		w.line("var %v = %v(stream, %d, %d);", count, w.helper("ReadLength"), t.LengthBitCount, t.MaxLength)
+		w.line("var %v = new %v(%v);", list, w.typeName(t), count)
+		w.open("for (var %v = 0; %v < %v; %v++)", index, index, count, index)
+		w.line("%v %v;", w.typeName(t.Elem), item)
+		w.readValue(t.Elem, item, depth+1)
+		w.line("%v.Add(%v);", list, item)
+		w.close()
+		w.line("%v = %v;", target, list)
+		w.close()
+	case codegen.TypeArray:
+		array := fmt.Sprintf("array%d", depth)
+		index := fmt.Sprintf("i%d", depth)
+		w.open("")
+		w.line("var %v = new %v[%d];", array, w.typeName(t.Elem), t.ArrayLength)
+		w.open("for (var %v = 0; %v < %d; %v++)", index, index, t.ArrayLength, index)
+		w.readValue(t.Elem, fmt.Sprintf("%v[%v]", array, index), depth+1)
+		w.close()
+		w.line("%v = %v;", target, array)
+		w.close()
+	}
+}
+
+func (w *csWriter) writeEnum(e *Enum) {
+	_, kind := EnumGoType(e)
+	w.open("public enum %v : %v", e.Name, csScalarName(kind))
+	for _, v := range e.Values {
+		w.line("%v = %d,", ExportedName(v.Name), v.Value)
+	}
+	w.close()
+	w.line("")
+}
+
+func (w *csWriter) writeMessage(m *Message, types []*codegen.Type) {
+	w.open("public class %v", m.Name)
+	for index, field := range m.Fields {
+		t := types[index]
+		w.line("public %v %v%v;", w.typeName(t), ExportedName(field.Name), w.initializer(t))
+	}
+	w.close()
+	w.line("")
+
+	w.open("public static class %vSerializer", m.Name)
+	w.open("public static void Serialize(IOutBitStream stream, %v v)", m.Name)
+	for index, field := range m.Fields {
+		w.writeValue(types[index], "v."+ExportedName(field.Name), 0)
+	}
+	w.close()
+	w.line("")
+	w.open("public static %v Deserialize(IInBitStream stream)", m.Name)
+	w.line("var v = new %v();", m.Name)
+	for index, field := range m.Fields {
+		w.readValue(types[index], "v."+ExportedName(field.Name), 0)
+	}
+	w.line("return v;")
+	w.close()
+	w.close()
+	w.line("")
+}
+
+var csHelpers = []struct {
+	name string
+	code string
+}{
+	{"WriteUnsignedBits", `public static void WriteUnsignedBits(IOutBitStream stream, ulong v, int bitCount)
+{
+    if (bitCount < 64 && v >> bitCount != 0)
+    {
+        throw new ArgumentOutOfRangeException(nameof(v), $"{v} does not fit in {bitCount} bits");
+    }
+    stream.WriteBits((uint)v, bitCount);
+}`},
+	{"WriteSignedBits", `public static void WriteSignedBits(IOutBitStream stream, long v, int bitCount)
+{
+    var magnitude = v < 0 ? (ulong)-v : (ulong)v;
+    if (magnitude >> (bitCount - 1) != 0)
+    {
+        throw new ArgumentOutOfRangeException(nameof(v), $"{v} does not fit in {bitCount} bits as sign and magnitude");
+    }
+    stream.WriteSignedBits((int)v, bitCount);
+}`},
+	{"WriteRange", `public static void WriteRange(IOutBitStream stream, long v, long min, long max)
+{
+    if (v < min || v > max)
+    {
+        throw new ArgumentOutOfRangeException(nameof(v), $"{v} is outside range {min}..{max}");
+    }
+    stream.WriteBits((uint)(v - min), RangeBitCount(min, max));
+}`},
+	{"ReadRange", `public static long ReadRange(IInBitStream stream, long min, long max)
+{
+    var v = min + stream.ReadBits(RangeBitCount(min, max));
+    if (v > max)
+    {
+        throw new InvalidDataException($"{v} is outside range {min}..{max}");
+    }
+    return v;
+}`},
+	{"WriteQuantized", `public static void WriteQuantized(IOutBitStream stream, double v, double min, double max, int bitCount)
+{
+    var steps = (double)((1UL << bitCount) - 1);
+    var normalized = (v - min) / (max - min);
+    if (normalized < 0 || double.IsNaN(normalized))
+    {
+        normalized = 0;
+    }
+    else if (normalized > 1)
+    {
+        normalized = 1;
+    }
+    stream.WriteBits((uint)Math.Round(normalized * steps, MidpointRounding.AwayFromZero), bitCount);
+}`},
+	{"ReadQuantized", `public static double ReadQuantized(IInBitStream stream, double min, double max, int bitCount)
+{
+    var steps = (double)((1UL << bitCount) - 1);
+    return min + stream.ReadBits(bitCount) / steps * (max - min);
+}`},
+	{"WriteLength", `public static void WriteLength(IOutBitStream stream, int length, int lengthBitCount, int maxLength)
+{
+    if (length > maxLength)
+    {
+        throw new ArgumentOutOfRangeException(nameof(length), $"length {length} exceeds max {maxLength}");
+    }
+    stream.WriteBits((uint)length, lengthBitCount);
+}`},
+	{"ReadLength", `public static int ReadLength(IInBitStream stream, int lengthBitCount, int maxLength)
+{
+    var length = stream.ReadBits(lengthBitCount);
+    if (length > maxLength)
+    {
+        throw new InvalidDataException($"length {length} exceeds max {maxLength}");
+    }
+    return (int)length;
+}`},
+	{"CheckArrayLength", `public static void CheckArrayLength(int length, int expectedLength)
+{
+    if (length != expectedLength)
+    {
+        throw new ArgumentException($"expected array length {expectedLength} but got {length}");
+    }
+}`},
+	{"WriteString", `public static void WriteString(IOutBitStream stream, string v, int lengthBitCount, int maxLength)
+{
+    var octets = Encoding.UTF8.GetBytes(v);
+    WriteLength(stream, octets.Length, lengthBitCount, maxLength);
+    foreach (var octet in octets)
+    {
+        stream.WriteBits(octet, 8);
+    }
+}`},
+	{"ReadString", `public static string ReadString(IInBitStream stream, int lengthBitCount, int maxLength)
+{
+    var octets = new byte[ReadLength(stream, lengthBitCount, maxLength)];
+    for (var i = 0; i < octets.Length; i++)
+    {
+        octets[i] = (byte)stream.ReadBits(8);
+    }
+    return new UTF8Encoding(false, true).GetString(octets);
+}`},
+}
+
+const csRangeBitCount = `static int RangeBitCount(long min, long max)
+{
+    var span = (ulong)(max - min);
+    var bitCount = 0;
+    while (span != 0)
+    {
+        bitCount++;
+        span >>= 1;
+    }
+    return bitCount;
+}`
+
+func (w *csWriter) writeHelpers() {
+	if w.helpers["WriteString"] {
+		w.helpers["WriteLength"] = true
+	}
+	if w.helpers["ReadString"] {
+		w.helpers["ReadLength"] = true
+	}
+	var blocks []string
+	for _, h := range csHelpers {
+		if w.helpers[h.name] {
+			blocks = append(blocks, h.code)
+		}
+	}
+	if w.helpers["WriteRange"] || w.helpers["ReadRange"] {
+		blocks = append(blocks, csRangeBitCount)
+	}
+	if len(blocks) == 0 {
+		return
+	}
+
+	w.open("static class %v", w.helperClass)
+	for index, block := range blocks {
+		if index > 0 {
+			w.line("")
+		}
+		for _, codeLine := range strings.Split(block, "\n") {
+			w.line("%v", codeLine)
+		}
+	}
+	w.close()
+}
+
+// GenerateCSharp : Emits C# classes and serializers targeting the Brook-Dotnet bit stream API
+// (Piot.Brook IOutBitStream and IInBitStream). The serializers write the same bits as GenerateGo.
+func GenerateCSharp(s *Schema, file CSharpFile) ([]byte, error) {
+	namespace := file.Namespace
+	if namespace == "" {
+		namespace = ExportedName(s.Package)
+	}
+	body := &csWriter{indent: 1, helperClass: ExportedName(s.Package) + "BrookHelpers", helpers: map[string]bool{}, schema: s}
+	for _, e := range s.Enums {
+		body.writeEnum(e)
+	}
+	for _, m := range s.Messages {
+		types := make([]*codegen.Type, len(m.Fields))
+		for index, field := range m.Fields {
+			t, typeErr := s.fieldType(field)
+			if typeErr != nil {
+				return nil, errorf(field.Line, "field %v.%v: %v", m.Name, field.Name, typeErr)
+			}
+			types[index] = t
+		}
+		body.writeMessage(m, types)
+	}
+	body.writeHelpers()
+
+	w := &csWriter{}
+	w.line("// <auto-generated>")
+	w.line("// Code generated by %v. DO NOT EDIT.", file.Generator)
+	w.line("// </auto-generated>")
+	w.line("")
+	w.line("using System;")
+	w.line("using System.Collections.Generic;")
+	w.line("using System.IO;")
+	w.line("using System.Text;")
+	w.line("using Piot.Brook;")
+	w.line("")
+	w.open("namespace %v", namespace)
+	w.buffer.WriteString(strings.TrimRight(body.buffer.String(), "\n") + "\n")
+	w.close()
+	return []byte(w.buffer.String()), nil
+}
@@ -0,0 +1,166 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package schema ...
+package schema
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/piot/brook-go/src/brookstruct"
+	"github.com/piot/brook-go/src/codegen"
+)
+
+// ExportedName : Field or value name as an exported identifier, e.g. "health" becomes "Health"
+func ExportedName(name string) string {
+	runes := []rune(name)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+// EnumGoType : Smallest unsigned Go type that holds all enum values
+func EnumGoType(e *Enum) (string, brookstruct.ScalarKind) {
+	switch {
+	case e.Bits <= 8:
+		return "uint8", brookstruct.KindUint8
+	case e.Bits <= 16:
+		return "uint16", brookstruct.KindUint16
+	}
+	return "uint32", brookstruct.KindUint32
+}
+
+func formatTagFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// brookTag returns the struct tag that makes brookstruct write the same bits as the generated code
+func brookTag(options brookstruct.Options) string {
+	var parts []string
+	if options.HasRange {
+		parts = append(parts, fmt.Sprintf("range=%d..%d", options.RangeMin, options.RangeMax))
+	}
+	if options.HasQuant {
+		parts = append(parts, fmt.Sprintf("quant=%v..%v", formatTagFloat(options.QuantMin), formatTagFloat(options.QuantMax)))
+	}
+	if options.Bits != 0 {
+		parts = append(parts, fmt.Sprintf("bits=%d", options.Bits))
+	}
+	if options.Max != 0 {
+		parts = append(parts, fmt.Sprintf("max=%d", options.Max))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" `brook:\"%v\"`", strings.Join(parts, ","))
+}
+
+func (s *Schema) fieldOptions(field *Field) brookstruct.Options {
+	options := field.Options
+	if e := s.Enum(field.Type.Name); e != nil {
+		options.Bits = e.Bits
+	}
+	return options
+}
+
+func (s *Schema) elementType(field *Field, options brookstruct.Options) (*codegen.Type, error) {
+	name := field.Type.Name
+	if e := s.Enum(name); e != nil {
+		_, kind := EnumGoType(e)
+		return codegen.NewScalar(e.Name, kind, options)
+	}
+	if s.Message(name) != nil {
+		return codegen.NewStructRef(name), nil
+	}
+	kind, _ := codegen.ScalarKindFromName(name)
+	return codegen.NewScalar(name, kind, options)
+}
+
+func (s *Schema) fieldType(field *Field) (*codegen.Type, error) {
+	options := s.fieldOptions(field)
+	elementOptions := options
+	if field.Type.Slice {
+		elementOptions.Max = 0
+	}
+	t, elementErr := s.elementType(field, elementOptions)
+	if elementErr != nil {
+		return nil, elementErr
+	}
+	switch {
+	case field.Type.Slice:
+		t = codegen.NewSlice(t, options)
+	case field.Type.ArrayLength > 0:
+		t = codegen.NewArray(t, field.Type.ArrayLength)
+	}
+	if field.Type.Optional {
+		t = codegen.NewPointer(t)
+	}
+	return t, nil
+}
+
+func (s *Schema) goDeclarations() string {
+	var b strings.Builder
+	for _, e := range s.Enums {
+		goType, _ := EnumGoType(e)
+		fmt.Fprintf(&b, "// %v : Enum written with %d bits\n", e.Name, e.Bits)
+		fmt.Fprintf(&b, "type %v %v\n\n", e.Name, goType)
+		b.WriteString("const (\n")
+		for _, v := range e.Values {
+			fmt.Fprintf(&b, "\t%v%v %v = %d\n", e.Name, ExportedName(v.Name), e.Name, v.Value)
+		}
+		b.WriteString(")\n\n")
+	}
+	for _, m := range s.Messages {
+		fmt.Fprintf(&b, "// %v : Message declared in the schema\n", m.Name)
+		fmt.Fprintf(&b, "type %v struct {\n", m.Name)
+		for _, field := range m.Fields {
+			t, _ := s.fieldType(field)
+			fmt.Fprintf(&b, "\t%v %v%v\n", ExportedName(field.Name), t.GoName, brookTag(s.fieldOptions(field)))
+		}
+		b.WriteString("}\n\n")
+	}
+	return b.String()
+}
+
+// GenerateGo : Emits Go types for the enums and messages together with MarshalBrook and UnmarshalBrook methods.
+// The methods write the same bits as brookstruct does for the generated types.
+func GenerateGo(s *Schema, generator string) ([]byte, error) {
+	file := codegen.GoFile{Package: s.Package, Generator: generator, Declarations: s.goDeclarations()}
+	for _, m := range s.Messages {
+		st := codegen.Struct{Name: m.Name}
+		for _, field := range m.Fields {
+			t, typeErr := s.fieldType(field)
+			if typeErr != nil {
+				return nil, errorf(field.Line, "field %v.%v: %v", m.Name, field.Name, typeErr)
+			}
+			st.Fields = append(st.Fields, codegen.Field{Name: ExportedName(field.Name), Type: t})
+		}
+		file.Structs = append(file.Structs, st)
+	}
+	return codegen.GenerateGo(file)
+}
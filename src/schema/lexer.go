@@ -0,0 +1,119 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package schema ...
+package schema
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind uint8
+
+const (
+	tokenIdentifier tokenKind = iota
+	tokenNumber
+	tokenSymbol
+	tokenEOF
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	line int
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of file"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// Error : Schema error with the line number where it was found
+type Error struct {
+	Line    int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("schema:%d: %v", e.Line, e.Message)
+}
+
+func errorf(line int, format string, args ...interface{}) *Error {
+	return &Error{Line: line, Message: fmt.Sprintf(format, args...)}
+}
+
+func isIdentifierRune(r rune, first bool) bool {
+	return r == '_' || unicode.IsLetter(r) || (!first && unicode.IsDigit(r))
+}
+
+func tokenize(source string) ([]token, error) {
+	var tokens []token
+	for lineIndex, line := range strings.Split(source, "\n") {
+		lineNumber := lineIndex + 1
+		if commentStart := strings.Index(line, "#"); commentStart >= 0 {
+			line = line[:commentStart]
+		}
+		if commentStart := strings.Index(line, "//"); commentStart >= 0 {
+			line = line[:commentStart]
+		}
+		runes := []rune(line)
+		for i := 0; i < len(runes); {
+			r := runes[i]
+			switch {
+			case unicode.IsSpace(r):
+				i++
+			case isIdentifierRune(r, true):
+				start := i
+				for i < len(runes) && isIdentifierRune(runes[i], false) {
+					i++
+				}
+				tokens = append(tokens, token{kind: tokenIdentifier, text: string(runes[start:i]), line: lineNumber})
+			case unicode.IsDigit(r) || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+				start := i
+				i++
+				for i < len(runes) && (unicode.IsDigit(runes[i]) || (runes[i] == '.' && !(i+1 < len(runes) && runes[i+1] == '.'))) {
+					i++
+				}
+				tokens = append(tokens, token{kind: tokenNumber, text: string(runes[start:i]), line: lineNumber})
+			case r == '.' && i+1 < len(runes) && runes[i+1] == '.':
+				tokens = append(tokens, token{kind: tokenSymbol, text: "..", line: lineNumber})
+				i += 2
+			case strings.ContainsRune("{}[]:=", r):
+				tokens = append(tokens, token{kind: tokenSymbol, text: string(r), line: lineNumber})
+				i++
+			default:
+				return nil, errorf(lineNumber, "unexpected character %q", r)
+			}
+		}
+	}
+	lastLine := strings.Count(source, "\n") + 1
+	tokens = append(tokens, token{kind: tokenEOF, line: lastLine})
+	return tokens, nil
+}
@@ -0,0 +1,117 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package schema parses brook schema files, a small language describing messages and enums
+// that is shared between Brook-Go and Brook-Dotnet.
+//
+//	package game
+//
+//	enum Weapon bits 3 {
+//		Sword
+//		Bow = 4
+//	}
+//
+//	message Player {
+//		id: uint16
+//		health: uint8 bits 7
+//		offset: int32 range -100..100
+//		aim: float32 quant 0..1 bits 10
+//		name: string max 16
+//		weapon: Weapon
+//		items: []Item max 8
+//		slots: [2]int16
+//		target: optional Vector
+//	}
+package schema
+
+import (
+	"github.com/piot/brook-go/src/brookstruct"
+)
+
+// Schema : A validated schema file
+type Schema struct {
+	Package  string
+	Enums    []*Enum
+	Messages []*Message
+}
+
+// Enum : Named integer values written with a fixed bit count
+type Enum struct {
+	Name   string
+	Bits   uint
+	Values []EnumValue
+	Line   int
+}
+
+// EnumValue : One named value in an enum
+type EnumValue struct {
+	Name  string
+	Value uint32
+	Line  int
+}
+
+// Message : A struct with serialized fields
+type Message struct {
+	Name   string
+	Fields []*Field
+	Line   int
+}
+
+// Field : A message field and its encoding options
+type Field struct {
+	Name    string
+	Type    TypeRef
+	Options brookstruct.Options
+	Line    int
+}
+
+// TypeRef : Field type. Name is a scalar, enum or message name
+type TypeRef struct {
+	Name        string
+	Slice       bool
+	ArrayLength int
+	Optional    bool
+}
+
+// Enum : Finds an enum by name
+func (s *Schema) Enum(name string) *Enum {
+	for _, e := range s.Enums {
+		if e.Name == name {
+			return e
+		}
+	}
+	return nil
+}
+
+// Message : Finds a message by name
+func (s *Schema) Message(name string) *Message {
+	for _, m := range s.Messages {
+		if m.Name == name {
+			return m
+		}
+	}
+	return nil
+}
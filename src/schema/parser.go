@@ -0,0 +1,323 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package schema ...
+package schema
+
+import (
+	"strconv"
+
+	"github.com/piot/brook-go/src/brookstruct"
+)
+
+type parser struct {
+	tokens   []token
+	position int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.position]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.position]
+	if t.kind != tokenEOF {
+		p.position++
+	}
+	return t
+}
+
+func (p *parser) expectSymbol(symbol string) error {
+	t := p.next()
+	if t.kind != tokenSymbol || t.text != symbol {
+		return errorf(t.line, "expected %q but found %v", symbol, t)
+	}
+	return nil
+}
+
+func (p *parser) expectIdentifier(what string) (token, error) {
+	t := p.next()
+	if t.kind != tokenIdentifier {
+		return t, errorf(t.line, "expected %v but found %v", what, t)
+	}
+	return t, nil
+}
+
+func (p *parser) isSymbol(symbol string) bool {
+	t := p.peek()
+	return t.kind == tokenSymbol && t.text == symbol
+}
+
+func (p *parser) expectUint(what string, bitSize int) (uint64, token, error) {
+	t := p.next()
+	if t.kind != tokenNumber {
+		return 0, t, errorf(t.line, "expected %v but found %v", what, t)
+	}
+	v, err := strconv.ParseUint(t.text, 10, bitSize)
+	if err != nil {
+		return 0, t, errorf(t.line, "illegal %v %v", what, t)
+	}
+	return v, t, nil
+}
+
+func (p *parser) expectRange(what string) (token, token, error) {
+	min := p.next()
+	if min.kind != tokenNumber {
+		return min, min, errorf(min.line, "expected %v min..max but found %v", what, min)
+	}
+	dotsErr := p.expectSymbol("..")
+	if dotsErr != nil {
+		return min, min, dotsErr
+	}
+	max := p.next()
+	if max.kind != tokenNumber {
+		return min, max, errorf(max.line, "expected %v max but found %v", what, max)
+	}
+	return min, max, nil
+}
+
+// Parse : Parses and validates a schema
+func Parse(source string) (*Schema, error) {
+	tokens, tokenizeErr := tokenize(source)
+	if tokenizeErr != nil {
+		return nil, tokenizeErr
+	}
+	p := &parser{tokens: tokens}
+	s, parseErr := p.parseSchema()
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	validateErr := validate(s)
+	if validateErr != nil {
+		return nil, validateErr
+	}
+	return s, nil
+}
+
+func (p *parser) parseSchema() (*Schema, error) {
+	s := &Schema{}
+	for {
+		t := p.next()
+		if t.kind == tokenEOF {
+			break
+		}
+		if t.kind != tokenIdentifier {
+			return nil, errorf(t.line, "expected package, enum or message but found %v", t)
+		}
+		switch t.text {
+		case "package":
+			if s.Package != "" {
+				return nil, errorf(t.line, "package is already set to %v", s.Package)
+			}
+			name, nameErr := p.expectIdentifier("package name")
+			if nameErr != nil {
+				return nil, nameErr
+			}
+			s.Package = name.text
+		case "enum":
+			e, enumErr := p.parseEnum(t.line)
+			if enumErr != nil {
+				return nil, enumErr
+			}
+			s.Enums = append(s.Enums, e)
+		case "message":
+			m, messageErr := p.parseMessage(t.line)
+			if messageErr != nil {
+				return nil, messageErr
+			}
+			s.Messages = append(s.Messages, m)
+		default:
+			return nil, errorf(t.line, "expected package, enum or message but found %v", t)
+		}
+	}
+	if s.Package == "" {
+		return nil, errorf(1, "missing package declaration")
+	}
+	return s, nil
+}
+
+func (p *parser) parseEnum(line int) (*Enum, error) {
+	name, nameErr := p.expectIdentifier("enum name")
+	if nameErr != nil {
+		return nil, nameErr
+	}
+	e := &Enum{Name: name.text, Line: line}
+	bitsKeyword, bitsKeywordErr := p.expectIdentifier("bits")
+	if bitsKeywordErr != nil || bitsKeyword.text != "bits" {
+		return nil, errorf(bitsKeyword.line, "expected bits after enum name but found %v", bitsKeyword)
+	}
+	bitCount, _, bitsErr := p.expectUint("bit count", 8)
+	if bitsErr != nil {
+		return nil, bitsErr
+	}
+	e.Bits = uint(bitCount)
+	openErr := p.expectSymbol("{")
+	if openErr != nil {
+		return nil, openErr
+	}
+	nextValue := uint64(0)
+	for !p.isSymbol("}") {
+		valueName, valueNameErr := p.expectIdentifier("enum value name")
+		if valueNameErr != nil {
+			return nil, valueNameErr
+		}
+		if p.isSymbol("=") {
+			p.next()
+			v, _, valueErr := p.expectUint("enum value", 32)
+			if valueErr != nil {
+				return nil, valueErr
+			}
+			nextValue = v
+		}
+		e.Values = append(e.Values, EnumValue{Name: valueName.text, Value: uint32(nextValue), Line: valueName.line})
+		nextValue++
+	}
+	p.next()
+	return e, nil
+}
+
+func (p *parser) parseMessage(line int) (*Message, error) {
+	name, nameErr := p.expectIdentifier("message name")
+	if nameErr != nil {
+		return nil, nameErr
+	}
+	m := &Message{Name: name.text, Line: line}
+	openErr := p.expectSymbol("{")
+	if openErr != nil {
+		return nil, openErr
+	}
+	for !p.isSymbol("}") {
+		field, fieldErr := p.parseField()
+		if fieldErr != nil {
+			return nil, fieldErr
+		}
+		m.Fields = append(m.Fields, field)
+	}
+	p.next()
+	return m, nil
+}
+
+func (p *parser) parseField() (*Field, error) {
+	name, nameErr := p.expectIdentifier("field name")
+	if nameErr != nil {
+		return nil, nameErr
+	}
+	field := &Field{Name: name.text, Line: name.line}
+	colonErr := p.expectSymbol(":")
+	if colonErr != nil {
+		return nil, colonErr
+	}
+
+	if p.peek().kind == tokenIdentifier && p.peek().text == "optional" {
+		p.next()
+		field.Type.Optional = true
+	}
+	if p.isSymbol("[") {
+		p.next()
+		if p.isSymbol("]") {
+			field.Type.Slice = true
+		} else {
+			length, lengthToken, lengthErr := p.expectUint("array length", 31)
+			if lengthErr != nil {
+				return nil, lengthErr
+			}
+			if length == 0 {
+				return nil, errorf(lengthToken.line, "array length must be at least 1")
+			}
+			field.Type.ArrayLength = int(length)
+		}
+		closeErr := p.expectSymbol("]")
+		if closeErr != nil {
+			return nil, closeErr
+		}
+	}
+	typeName, typeErr := p.expectIdentifier("type name")
+	if typeErr != nil {
+		return nil, typeErr
+	}
+	field.Type.Name = typeName.text
+
+	for p.peek().kind == tokenIdentifier && p.peek().line == name.line {
+		optionErr := p.parseOption(&field.Options)
+		if optionErr != nil {
+			return nil, optionErr
+		}
+	}
+	return field, nil
+}
+
+func (p *parser) parseOption(options *brookstruct.Options) error {
+	option := p.next()
+	switch option.text {
+	case "bits":
+		v, _, err := p.expectUint("bit count", 8)
+		if err != nil {
+			return err
+		}
+		if v == 0 {
+			return errorf(option.line, "bits must be at least 1")
+		}
+		options.Bits = uint(v)
+	case "max":
+		v, _, err := p.expectUint("max length", 32)
+		if err != nil {
+			return err
+		}
+		if v == 0 {
+			return errorf(option.line, "max must be at least 1")
+		}
+		options.Max = uint(v)
+	case "range":
+		min, max, err := p.expectRange("range")
+		if err != nil {
+			return err
+		}
+		minValue, minErr := strconv.ParseInt(min.text, 10, 64)
+		maxValue, maxErr := strconv.ParseInt(max.text, 10, 64)
+		if minErr != nil || maxErr != nil || minValue >= maxValue {
+			return errorf(option.line, "illegal range %v..%v", min.text, max.text)
+		}
+		options.HasRange = true
+		options.RangeMin = minValue
+		options.RangeMax = maxValue
+	case "quant":
+		min, max, err := p.expectRange("quant")
+		if err != nil {
+			return err
+		}
+		minValue, minErr := strconv.ParseFloat(min.text, 64)
+		maxValue, maxErr := strconv.ParseFloat(max.text, 64)
+		if minErr != nil || maxErr != nil || minValue >= maxValue {
+			return errorf(option.line, "illegal quant %v..%v", min.text, max.text)
+		}
+		options.HasQuant = true
+		options.QuantMin = minValue
+		options.QuantMax = maxValue
+	default:
+		return errorf(option.line, "unknown field option %v, expected bits, max, range or quant", option)
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package schema
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+const testSchema = `
+package game
+
+# Weapons fit in three bits
+enum Weapon bits 3 {
+	Sword
+	Bow = 4
+	Staff
+}
+
+message Player {
+	id: uint16
+	health: uint8 bits 7 // comment after a field
+	offset: int32 range -100..100
+	aim: float32 quant 0..1 bits 10
+	name: string max 16
+	weapon: Weapon
+	items: []Item max 8
+	slots: [2]int16
+	target: optional Item
+}
+
+message Item {
+	count: uint8 range 1..99
+}
+`
+
+func TestParse(t *testing.T) {
+	s, err := Parse(testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Package != "game" || len(s.Enums) != 1 || len(s.Messages) != 2 {
+		t.Fatalf("Unexpected schema %+v", s)
+	}
+
+	weapon := s.Enum("Weapon")
+	if weapon.Bits != 3 || len(weapon.Values) != 3 || weapon.Values[1].Value != 4 || weapon.Values[2].Value != 5 {
+		t.Errorf("Unexpected enum %+v", weapon)
+	}
+
+	player := s.Message("Player")
+	if len(player.Fields) != 9 {
+		t.Fatalf("Expected 9 fields but got %v", len(player.Fields))
+	}
+	health := player.Fields[1]
+	if health.Name != "health" || health.Options.Bits != 7 || health.Line != 13 {
+		t.Errorf("Unexpected field %+v", health)
+	}
+	offset := player.Fields[2].Options
+	if !offset.HasRange || offset.RangeMin != -100 || offset.RangeMax != 100 {
+		t.Errorf("Unexpected range %+v", offset)
+	}
+	aim := player.Fields[3].Options
+	if !aim.HasQuant || aim.QuantMin != 0 || aim.QuantMax != 1 || aim.Bits != 10 {
+		t.Errorf("Unexpected quant %+v", aim)
+	}
+	items := player.Fields[6]
+	if !items.Type.Slice || items.Type.Name != "Item" || items.Options.Max != 8 {
+		t.Errorf("Unexpected slice %+v", items)
+	}
+	if player.Fields[7].Type.ArrayLength != 2 || !player.Fields[8].Type.Optional {
+		t.Errorf("Unexpected array or optional %+v %+v", player.Fields[7], player.Fields[8])
+	}
+}
+
+func TestGenerateGo(t *testing.T) {
+	s, err := Parse(testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source, generateErr := GenerateGo(s, "test")
+	if generateErr != nil {
+		t.Fatal(generateErr)
+	}
+	expected := []string{
+		"package game",
+		"type Weapon uint8",
+		"WeaponBow   Weapon = 4",
+		"Health uint8   `brook:\"bits=7\"`",
+		"Aim    float32 `brook:\"quant=0..1,bits=10\"`",
+		"Weapon Weapon  `brook:\"bits=3\"`",
+		"func (v *Player) MarshalBrook(out outbitstream.OutBitStream) error",
+	}
+	for _, e := range expected {
+		if !strings.Contains(string(source), e) {
+			t.Errorf("Expected generated code to contain %q:\n%s", e, source)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		source  string
+		line    int
+		message string
+	}{
+		{"package a\nmessage A {\n\tx: Missing\n}", 3, "unknown type Missing"},
+		{"package a\nmessage A {\n\tx: uint8\n\tx: uint16\n}", 4, "already declared"},
+		{"package a\nenum E bits 2 {\n\tA\n\tB = 4\n}", 4, "does not fit in 2 bits"},
+		{"package a\nmessage A {\n\tx: float32 range 0..9\n}", 3, "range is only supported for integers"},
+		{"package a\nmessage A {\n\tx: uint8 speed 9\n}", 3, "unknown field option"},
+		{"package a\nmessage A {\n\tx uint8\n}", 3, "expected \":\""},
+		{"package a\n\nmessage A {\n\tx: A\n}", 4, "contains itself"},
+		{"package a\nmessage A {}\nmessage A {}", 3, "already declared on line 2"},
+		{"package a\nmessage A {\n\tx: uint8 range 5..1\n}", 3, "illegal range"},
+		{"package a\nmessage A {\n\tx: uint8 max 3\n}", 3, "max is only supported"},
+		{"package a\nmessage A {\n\tx: uint8 $\n}", 3, "unexpected character"},
+		{"message A {}", 1, "missing package"},
+		{"package a\nmessage A {\n\tx: uint8\n", 4, "expected field name but found end of file"},
+	}
+	for _, test := range tests {
+		_, err := Parse(test.source)
+		var schemaErr *Error
+		if !errors.As(err, &schemaErr) {
+			t.Errorf("Expected schema error for %q but got %v", test.source, err)
+			continue
+		}
+		if schemaErr.Line != test.line || !strings.Contains(schemaErr.Message, test.message) {
+			t.Errorf("Expected line %v %q but got %v", test.line, test.message, err)
+		}
+	}
+}
+
+func TestOptionalSelfReference(t *testing.T) {
+	_, err := Parse("package a\nmessage Node {\n\tnext: optional Node\n\tchildren: []Node\n}")
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGenerateCSharpGolden(t *testing.T) {
+	source, readErr := os.ReadFile("testdata/game.brook")
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	s, err := Parse(string(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	generated, generateErr := GenerateCSharp(s, CSharpFile{Generator: "brookschema"})
+	if generateErr != nil {
+		t.Fatal(generateErr)
+	}
+	if *update {
+		os.WriteFile("testdata/game.cs.golden", generated, 0o644)
+	}
+	expected, goldenErr := os.ReadFile("testdata/game.cs.golden")
+	if goldenErr != nil {
+		t.Fatal(goldenErr)
+	}
+	if string(generated) != string(expected) {
+		t.Errorf("Generated C# differs from testdata/game.cs.golden, run go test -update if the change is intended:\n%s", generated)
+	}
+}
+
+func TestCSharpNamespace(t *testing.T) {
+	s, err := Parse("package game\nmessage A {\n\tx: uint8\n}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	generated, _ := GenerateCSharp(s, CSharpFile{Namespace: "Studio.Game", Generator: "test"})
+	if !strings.Contains(string(generated), "namespace Studio.Game\n{") || strings.Contains(string(generated), "BrookHelpers") {
+		t.Errorf("Unexpected C#:\n%s", generated)
+	}
+}
@@ -0,0 +1,188 @@
+/*
+
+MIT License
+
+Copyright (c) 2017 Peter Bjorklund
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package schema ...
+package schema
+
+import (
+	"unicode"
+
+	"github.com/piot/brook-go/src/brookstruct"
+	"github.com/piot/brook-go/src/codegen"
+)
+
+func validate(s *Schema) error {
+	names := map[string]int{}
+	declare := func(name string, line int) error {
+		if !startsWithLetter(name) {
+			return errorf(line, "%v must start with a letter", name)
+		}
+		if _, isScalar := codegen.ScalarKindFromName(name); isScalar {
+			return errorf(line, "%v is a built in type name", name)
+		}
+		if previousLine, exists := names[name]; exists {
+			return errorf(line, "%v is already declared on line %d", name, previousLine)
+		}
+		names[name] = line
+		return nil
+	}
+
+	for _, e := range s.Enums {
+		declareErr := declare(e.Name, e.Line)
+		if declareErr != nil {
+			return declareErr
+		}
+		enumErr := validateEnum(e)
+		if enumErr != nil {
+			return enumErr
+		}
+	}
+	for _, m := range s.Messages {
+		declareErr := declare(m.Name, m.Line)
+		if declareErr != nil {
+			return declareErr
+		}
+	}
+	for _, m := range s.Messages {
+		messageErr := validateMessage(s, m)
+		if messageErr != nil {
+			return messageErr
+		}
+	}
+	return validateNoValueCycles(s)
+}
+
+// startsWithLetter makes sure the name becomes exported in generated code
+func startsWithLetter(name string) bool {
+	for _, r := range name {
+		return unicode.IsLetter(r)
+	}
+	return false
+}
+
+func validateEnum(e *Enum) error {
+	if e.Bits == 0 || e.Bits > 32 {
+		return errorf(e.Line, "enum %v bits must be 1..32", e.Name)
+	}
+	if len(e.Values) == 0 {
+		return errorf(e.Line, "enum %v has no values", e.Name)
+	}
+	valueNames := map[string]bool{}
+	for _, v := range e.Values {
+		if !startsWithLetter(v.Name) {
+			return errorf(v.Line, "enum value %v.%v must start with a letter", e.Name, v.Name)
+		}
+		exportedName := ExportedName(v.Name)
+		if valueNames[exportedName] {
+			return errorf(v.Line, "enum value %v.%v is already declared", e.Name, v.Name)
+		}
+		valueNames[exportedName] = true
+		if e.Bits < 32 && uint64(v.Value) >= uint64(1)<<e.Bits {
+			return errorf(v.Line, "enum value %v.%v = %d does not fit in %d bits", e.Name, v.Name, v.Value, e.Bits)
+		}
+	}
+	return nil
+}
+
+func validateMessage(s *Schema, m *Message) error {
+	fieldNames := map[string]bool{}
+	for _, field := range m.Fields {
+		if !startsWithLetter(field.Name) {
+			return errorf(field.Line, "field %v.%v must start with a letter", m.Name, field.Name)
+		}
+		exportedName := ExportedName(field.Name)
+		if fieldNames[exportedName] {
+			return errorf(field.Line, "field %v.%v is already declared", m.Name, field.Name)
+		}
+		fieldNames[exportedName] = true
+
+		if field.Options.Max != 0 && !field.Type.Slice && field.Type.Name != "string" {
+			return errorf(field.Line, "field %v.%v: max is only supported for strings and slices", m.Name, field.Name)
+		}
+
+		if kind, isScalar := codegen.ScalarKindFromName(field.Type.Name); isScalar {
+			if field.Type.Name == "byte" || field.Type.Name == "rune" {
+				return errorf(field.Line, "field %v.%v: use uint8 or int32 instead of %v", m.Name, field.Name, field.Type.Name)
+			}
+			_, planErr := brookstruct.PlanScalar(kind, field.Options)
+			if planErr != nil {
+				return errorf(field.Line, "field %v.%v: %v", m.Name, field.Name, planErr)
+			}
+			continue
+		}
+		if s.Enum(field.Type.Name) != nil || s.Message(field.Type.Name) != nil {
+			if field.Options.Bits != 0 || field.Options.HasRange || field.Options.HasQuant {
+				return errorf(field.Line, "field %v.%v: options are not supported for %v", m.Name, field.Name, field.Type.Name)
+			}
+			continue
+		}
+		return errorf(field.Line, "field %v.%v: unknown type %v", m.Name, field.Name, field.Type.Name)
+	}
+	return nil
+}
+
+// validateNoValueCycles makes sure a message does not contain itself without an optional or slice in between
+func validateNoValueCycles(s *Schema) error {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[string]int{}
+	var visit func(m *Message) error
+	visit = func(m *Message) error {
+		state[m.Name] = visiting
+		for _, field := range m.Fields {
+			if field.Type.Optional || field.Type.Slice {
+				continue
+			}
+			child := s.Message(field.Type.Name)
+			if child == nil {
+				continue
+			}
+			if state[child.Name] == visiting {
+				return errorf(field.Line, "field %v.%v: message %v contains itself, use optional or a slice", m.Name, field.Name, child.Name)
+			}
+			if state[child.Name] == unvisited {
+				childErr := visit(child)
+				if childErr != nil {
+					return childErr
+				}
+			}
+		}
+		state[m.Name] = visited
+		return nil
+	}
+	for _, m := range s.Messages {
+		if state[m.Name] == unvisited {
+			visitErr := visit(m)
+			if visitErr != nil {
+				return visitErr
+			}
+		}
+	}
+	return nil
+}